@@ -1,9 +1,15 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"log/slog"
+	"math"
+	"path"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -94,6 +100,391 @@ var (
 		"Ratio of DaemonSet overhead to allocatable for nodes in this label group (0.0-1.0+)",
 		[]string{"label_group", "label_group_value", "resource"}, nil,
 	)
+	nodeStaticPodOverhead = prometheus.NewDesc(
+		"kube_binpacking_node_static_pod_overhead",
+		"Total resource requested by static (mirror) pods on this node",
+		[]string{"node", "resource"}, nil,
+	)
+	nodeStaticPodOverheadRatio = prometheus.NewDesc(
+		"kube_binpacking_node_static_pod_overhead_ratio",
+		"Ratio of static pod overhead to allocatable (0.0-1.0+)",
+		[]string{"node", "resource"}, nil,
+	)
+	clusterStaticPodOverhead = prometheus.NewDesc(
+		"kube_binpacking_cluster_static_pod_overhead",
+		"Cluster-wide total resource requested by static (mirror) pods",
+		[]string{"resource"}, nil,
+	)
+	clusterStaticPodOverheadRatio = prometheus.NewDesc(
+		"kube_binpacking_cluster_static_pod_overhead_ratio",
+		"Cluster-wide static pod overhead ratio",
+		[]string{"resource"}, nil,
+	)
+	groupStaticPodOverhead = prometheus.NewDesc(
+		"kube_binpacking_group_static_pod_overhead",
+		"Total resource requested by static (mirror) pods on nodes in this label group",
+		[]string{"label_group", "label_group_value", "resource"}, nil,
+	)
+	groupStaticPodOverheadRatio = prometheus.NewDesc(
+		"kube_binpacking_group_static_pod_overhead_ratio",
+		"Ratio of static pod overhead to allocatable for nodes in this label group (0.0-1.0+)",
+		[]string{"label_group", "label_group_value", "resource"}, nil,
+	)
+	nodeClassOverhead = prometheus.NewDesc(
+		"kube_binpacking_node_class_overhead",
+		"Total resource requested by pods in this overhead class on this node",
+		[]string{"node", "overhead_class", "resource"}, nil,
+	)
+	nodeClassOverheadRatio = prometheus.NewDesc(
+		"kube_binpacking_node_class_overhead_ratio",
+		"Ratio of overhead class requests to allocatable (0.0-1.0+)",
+		[]string{"node", "overhead_class", "resource"}, nil,
+	)
+	clusterClassOverhead = prometheus.NewDesc(
+		"kube_binpacking_cluster_class_overhead",
+		"Cluster-wide total resource requested by pods in this overhead class",
+		[]string{"overhead_class", "resource"}, nil,
+	)
+	clusterClassOverheadRatio = prometheus.NewDesc(
+		"kube_binpacking_cluster_class_overhead_ratio",
+		"Cluster-wide overhead class ratio",
+		[]string{"overhead_class", "resource"}, nil,
+	)
+	groupClassOverhead = prometheus.NewDesc(
+		"kube_binpacking_group_class_overhead",
+		"Total resource requested by pods in this overhead class on nodes in this label group",
+		[]string{"label_group", "label_group_value", "overhead_class", "resource"}, nil,
+	)
+	groupClassOverheadRatio = prometheus.NewDesc(
+		"kube_binpacking_group_class_overhead_ratio",
+		"Ratio of overhead class requests to allocatable for nodes in this label group (0.0-1.0+)",
+		[]string{"label_group", "label_group_value", "overhead_class", "resource"}, nil,
+	)
+	nodePodOverhead = prometheus.NewDesc(
+		"kube_binpacking_node_pod_overhead",
+		"Total RuntimeClass pod overhead included in the allocated resource on this node",
+		[]string{"node", "resource"}, nil,
+	)
+	clusterPodOverhead = prometheus.NewDesc(
+		"kube_binpacking_cluster_pod_overhead",
+		"Cluster-wide total RuntimeClass pod overhead included in the allocated resource",
+		[]string{"resource"}, nil,
+	)
+	groupPodOverhead = prometheus.NewDesc(
+		"kube_binpacking_group_pod_overhead",
+		"Total RuntimeClass pod overhead included in the allocated resource on nodes in this label group",
+		[]string{"label_group", "label_group_value", "resource"}, nil,
+	)
+	nodeWorkloadAllocated = prometheus.NewDesc(
+		"kube_binpacking_node_workload_allocated",
+		"Total resource requested by pods on this node by the kind of workload owning them",
+		[]string{"node", "workload_kind", "resource"}, nil,
+	)
+	clusterWorkloadAllocated = prometheus.NewDesc(
+		"kube_binpacking_cluster_workload_allocated",
+		"Cluster-wide total resource requested by pods by the kind of workload owning them",
+		[]string{"workload_kind", "resource"}, nil,
+	)
+	groupWorkloadAllocated = prometheus.NewDesc(
+		"kube_binpacking_group_workload_allocated",
+		"Total resource requested by pods on nodes in this label group by the kind of workload owning them",
+		[]string{"label_group", "label_group_value", "workload_kind", "resource"}, nil,
+	)
+	nodeQOSAllocated = prometheus.NewDesc(
+		"kube_binpacking_node_qos_allocated",
+		"Total resource requested by pods on this node by pod QoS class",
+		[]string{"node", "qos_class", "resource"}, nil,
+	)
+	nodeQOSPods = prometheus.NewDesc(
+		"kube_binpacking_node_qos_pods",
+		"Number of pods on this node by pod QoS class",
+		[]string{"node", "qos_class"}, nil,
+	)
+	clusterQOSAllocated = prometheus.NewDesc(
+		"kube_binpacking_cluster_qos_allocated",
+		"Cluster-wide total resource requested by pods by pod QoS class",
+		[]string{"qos_class", "resource"}, nil,
+	)
+	clusterQOSPods = prometheus.NewDesc(
+		"kube_binpacking_cluster_qos_pods",
+		"Cluster-wide number of scheduled pods by pod QoS class",
+		[]string{"qos_class"}, nil,
+	)
+	groupQOSAllocated = prometheus.NewDesc(
+		"kube_binpacking_group_qos_allocated",
+		"Total resource requested by pods on nodes in this label group by pod QoS class",
+		[]string{"label_group", "label_group_value", "qos_class", "resource"}, nil,
+	)
+	groupQOSPods = prometheus.NewDesc(
+		"kube_binpacking_group_qos_pods",
+		"Number of pods on nodes in this label group by pod QoS class",
+		[]string{"label_group", "label_group_value", "qos_class"}, nil,
+	)
+	clusterNamespaceAllocated = prometheus.NewDesc(
+		"kube_binpacking_cluster_namespace_allocated",
+		"Cluster-wide total resource requested by pods in this namespace; namespaces outside the top N are summed as <other>",
+		[]string{"namespace", "resource"}, nil,
+	)
+	groupNamespaceAllocated = prometheus.NewDesc(
+		"kube_binpacking_group_namespace_allocated",
+		"Total resource requested by pods in this namespace on nodes in this label group; namespaces outside the top N are summed as <other>",
+		[]string{"label_group", "label_group_value", "namespace", "resource"}, nil,
+	)
+	nodePriorityClassAllocated = prometheus.NewDesc(
+		"kube_binpacking_node_priority_class_allocated",
+		"Total resource requested by pods on this node by PriorityClass",
+		[]string{"node", "priority_class", "resource"}, nil,
+	)
+	clusterPriorityClassAllocated = prometheus.NewDesc(
+		"kube_binpacking_cluster_priority_class_allocated",
+		"Cluster-wide total resource requested by pods by PriorityClass",
+		[]string{"priority_class", "resource"}, nil,
+	)
+	groupPriorityClassAllocated = prometheus.NewDesc(
+		"kube_binpacking_group_priority_class_allocated",
+		"Total resource requested by pods on nodes in this label group by PriorityClass",
+		[]string{"label_group", "label_group_value", "priority_class", "resource"}, nil,
+	)
+	clusterPriorityEffectiveFree = prometheus.NewDesc(
+		"kube_binpacking_cluster_priority_effective_free",
+		"Cluster-wide allocatable resource free for pods of at least this priority, counting requests of lower-priority pods as preemptible",
+		[]string{"resource", "min_priority"}, nil,
+	)
+	groupPriorityEffectiveFree = prometheus.NewDesc(
+		"kube_binpacking_group_priority_effective_free",
+		"Allocatable resource free for pods of at least this priority on nodes in this label group, counting requests of lower-priority pods as preemptible",
+		[]string{"label_group", "label_group_value", "resource", "min_priority"}, nil,
+	)
+	nodeTerminatingAllocated = prometheus.NewDesc(
+		"kube_binpacking_node_terminating_allocated",
+		"Total resource requested by terminating pods (with a deletion timestamp) on this node",
+		[]string{"node", "resource"}, nil,
+	)
+	clusterTerminatingAllocated = prometheus.NewDesc(
+		"kube_binpacking_cluster_terminating_allocated",
+		"Cluster-wide total resource requested by terminating pods (with a deletion timestamp)",
+		[]string{"resource"}, nil,
+	)
+	groupTerminatingAllocated = prometheus.NewDesc(
+		"kube_binpacking_group_terminating_allocated",
+		"Total resource requested by terminating pods (with a deletion timestamp) on nodes in this label group",
+		[]string{"label_group", "label_group_value", "resource"}, nil,
+	)
+	nodeResizingPods = prometheus.NewDesc(
+		"kube_binpacking_node_resizing_pods",
+		"Number of pods on this node with an outstanding in-place resize, by resize state",
+		[]string{"node", "resize_state"}, nil,
+	)
+	clusterResizingPods = prometheus.NewDesc(
+		"kube_binpacking_cluster_resizing_pods",
+		"Cluster-wide number of pods with an outstanding in-place resize, by resize state",
+		[]string{"resize_state"}, nil,
+	)
+	nodeCapacity = prometheus.NewDesc(
+		"kube_binpacking_node_capacity",
+		"Total resource capacity of this node",
+		[]string{"node", "resource"}, nil,
+	)
+	nodeReservedRatio = prometheus.NewDesc(
+		"kube_binpacking_node_reserved_ratio",
+		"Ratio of capacity reserved for the system and kubelet (capacity minus allocatable) to capacity",
+		[]string{"node", "resource"}, nil,
+	)
+	clusterCapacity = prometheus.NewDesc(
+		"kube_binpacking_cluster_capacity",
+		"Cluster-wide total resource capacity",
+		[]string{"resource"}, nil,
+	)
+	clusterReservedRatio = prometheus.NewDesc(
+		"kube_binpacking_cluster_reserved_ratio",
+		"Cluster-wide ratio of capacity reserved for the system and kubelet to capacity",
+		[]string{"resource"}, nil,
+	)
+	groupCapacity = prometheus.NewDesc(
+		"kube_binpacking_group_capacity",
+		"Total resource capacity of nodes in this label group",
+		[]string{"label_group", "label_group_value", "resource"}, nil,
+	)
+	groupReservedRatio = prometheus.NewDesc(
+		"kube_binpacking_group_reserved_ratio",
+		"Ratio of capacity reserved for the system and kubelet to capacity for nodes in this label group",
+		[]string{"label_group", "label_group_value", "resource"}, nil,
+	)
+	nodeStranded = prometheus.NewDesc(
+		"kube_binpacking_node_stranded",
+		"Unallocated resource on this node that is stranded because another tracked resource is saturated",
+		[]string{"node", "resource"}, nil,
+	)
+	nodeStrandedRatio = prometheus.NewDesc(
+		"kube_binpacking_node_stranded_ratio",
+		"Ratio of stranded resource to allocatable on this node",
+		[]string{"node", "resource"}, nil,
+	)
+	clusterStranded = prometheus.NewDesc(
+		"kube_binpacking_cluster_stranded",
+		"Cluster-wide unallocated resource on nodes where another tracked resource is saturated",
+		[]string{"resource"}, nil,
+	)
+	clusterStrandedRatio = prometheus.NewDesc(
+		"kube_binpacking_cluster_stranded_ratio",
+		"Cluster-wide ratio of stranded resource to allocatable",
+		[]string{"resource"}, nil,
+	)
+	groupStranded = prometheus.NewDesc(
+		"kube_binpacking_group_stranded",
+		"Unallocated resource on nodes in this label group where another tracked resource is saturated",
+		[]string{"label_group", "label_group_value", "resource"}, nil,
+	)
+	groupStrandedRatio = prometheus.NewDesc(
+		"kube_binpacking_group_stranded_ratio",
+		"Ratio of stranded resource to allocatable for nodes in this label group",
+		[]string{"label_group", "label_group_value", "resource"}, nil,
+	)
+	nodeDominantUtilization = prometheus.NewDesc(
+		"kube_binpacking_node_dominant_utilization_ratio",
+		"Highest utilization ratio across the tracked resources on this node, labelled with the resource it comes from",
+		[]string{"node", "dominant_resource"}, nil,
+	)
+	clusterDominantUtilization = prometheus.NewDesc(
+		"kube_binpacking_cluster_dominant_utilization_ratio",
+		"Average of the per-node dominant utilization ratio across the cluster",
+		nil, nil,
+	)
+	groupDominantUtilization = prometheus.NewDesc(
+		"kube_binpacking_group_dominant_utilization_ratio",
+		"Average of the per-node dominant utilization ratio for nodes in this label group",
+		[]string{"label_group", "label_group_value"}, nil,
+	)
+	clusterNodeUtilizationHistogram = prometheus.NewDesc(
+		"kube_binpacking_cluster_node_utilization",
+		"Distribution of node utilization ratios (allocated/allocatable) across the cluster",
+		[]string{"resource"}, nil,
+	)
+	groupNodeUtilizationHistogram = prometheus.NewDesc(
+		"kube_binpacking_group_node_utilization",
+		"Distribution of node utilization ratios (allocated/allocatable) for nodes in this label group",
+		[]string{"label_group", "label_group_value", "resource"}, nil,
+	)
+	nodeConsolidationCandidate = prometheus.NewDesc(
+		"kube_binpacking_node_consolidation_candidate",
+		"Whether the node's utilization is below the consolidation threshold (1) or not (0); resource is \"dominant\" in dominant mode",
+		[]string{"node", "resource"}, nil,
+	)
+	clusterUnderutilizedNodes = prometheus.NewDesc(
+		"kube_binpacking_cluster_underutilized_nodes",
+		"Number of nodes whose utilization is below the consolidation threshold; resource is \"dominant\" in dominant mode",
+		[]string{"resource"}, nil,
+	)
+	groupUnderutilizedNodes = prometheus.NewDesc(
+		"kube_binpacking_group_underutilized_nodes",
+		"Number of nodes in this label group whose utilization is below the consolidation threshold; resource is \"dominant\" in dominant mode",
+		[]string{"label_group", "label_group_value", "resource"}, nil,
+	)
+	groupNodesRequired = prometheus.NewDesc(
+		"kube_binpacking_group_nodes_required",
+		"Simulated number of this label group's nodes needed to host its pods other than DaemonSet and static pods, by estimation method",
+		[]string{"label_group", "label_group_value", "method"}, nil,
+	)
+	groupNodeUtilizationQuantile = prometheus.NewDesc(
+		"kube_binpacking_group_node_utilization_ratio_quantile",
+		"Quantile of the node utilization ratios in this label group (0 = min, 0.5 = median, 0.9 = p90, 1 = max)",
+		[]string{"label_group", "label_group_value", "resource", "quantile"}, nil,
+	)
+	groupNodeUtilizationStddev = prometheus.NewDesc(
+		"kube_binpacking_group_node_utilization_ratio_stddev",
+		"Standard deviation of the node utilization ratios in this label group, an indicator of imbalance",
+		[]string{"label_group", "label_group_value", "resource"}, nil,
+	)
+	clusterLargestFree = prometheus.NewDesc(
+		"kube_binpacking_cluster_largest_free",
+		"Largest unallocated resource (allocatable minus allocated) on any single node in the cluster",
+		[]string{"resource"}, nil,
+	)
+	clusterFragmentationIndex = prometheus.NewDesc(
+		"kube_binpacking_cluster_fragmentation_index",
+		"Cluster-wide fragmentation of free resource: 1 - largest free on a single node / total free (0 = all free resource on one node)",
+		[]string{"resource"}, nil,
+	)
+	groupLargestFree = prometheus.NewDesc(
+		"kube_binpacking_group_largest_free",
+		"Largest unallocated resource (allocatable minus allocated) on any single node in this label group",
+		[]string{"label_group", "label_group_value", "resource"}, nil,
+	)
+	groupFragmentationIndex = prometheus.NewDesc(
+		"kube_binpacking_group_fragmentation_index",
+		"Fragmentation of free resource in this label group: 1 - largest free on a single node / total free (0 = all free resource on one node)",
+		[]string{"label_group", "label_group_value", "resource"}, nil,
+	)
+	nodeLimitsAllocated = prometheus.NewDesc(
+		"kube_binpacking_node_limits_allocated",
+		"Total resource limits of pods on this node",
+		[]string{"node", "resource"}, nil,
+	)
+	nodeOvercommitRatio = prometheus.NewDesc(
+		"kube_binpacking_node_overcommit_ratio",
+		"Ratio of limits allocated to allocatable (0.0-1.0+)",
+		[]string{"node", "resource"}, nil,
+	)
+	nodePodsWithoutLimits = prometheus.NewDesc(
+		"kube_binpacking_node_pods_without_limits",
+		"Number of pods on this node with a container that sets no limit for the resource",
+		[]string{"node", "resource"}, nil,
+	)
+	clusterLimitsAllocated = prometheus.NewDesc(
+		"kube_binpacking_cluster_limits_allocated",
+		"Cluster-wide total resource limits",
+		[]string{"resource"}, nil,
+	)
+	clusterOvercommitRatio = prometheus.NewDesc(
+		"kube_binpacking_cluster_overcommit_ratio",
+		"Cluster-wide ratio of limits allocated to allocatable",
+		[]string{"resource"}, nil,
+	)
+	clusterPodsWithoutLimits = prometheus.NewDesc(
+		"kube_binpacking_cluster_pods_without_limits",
+		"Cluster-wide number of pods with a container that sets no limit for the resource",
+		[]string{"resource"}, nil,
+	)
+	groupLimitsAllocated = prometheus.NewDesc(
+		"kube_binpacking_group_limits_allocated",
+		"Total resource limits of pods on nodes in this label group",
+		[]string{"label_group", "label_group_value", "resource"}, nil,
+	)
+	groupOvercommitRatio = prometheus.NewDesc(
+		"kube_binpacking_group_overcommit_ratio",
+		"Ratio of limits allocated to allocatable for nodes in this label group (0.0-1.0+)",
+		[]string{"label_group", "label_group_value", "resource"}, nil,
+	)
+	groupPodsWithoutLimits = prometheus.NewDesc(
+		"kube_binpacking_group_pods_without_limits",
+		"Number of pods on nodes in this label group with a container that sets no limit for the resource",
+		[]string{"label_group", "label_group_value", "resource"}, nil,
+	)
+	pendingRequested = prometheus.NewDesc(
+		"kube_binpacking_pending_requested",
+		"Total resource requested by pending pods that are not yet scheduled to a node",
+		[]string{"resource"}, nil,
+	)
+	pendingPods = prometheus.NewDesc(
+		"kube_binpacking_pending_pods",
+		"Number of pending pods that are not yet scheduled to a node",
+		nil, nil,
+	)
+	pendingOldestAge = prometheus.NewDesc(
+		"kube_binpacking_pending_oldest_age_seconds",
+		"Age of the oldest pending pod that is not yet scheduled to a node (0 if none)",
+		nil, nil,
+	)
+	namespacePendingRequested = prometheus.NewDesc(
+		"kube_binpacking_namespace_pending_requested",
+		"Total resource requested by unscheduled pending pods in this namespace",
+		[]string{"namespace", "resource"}, nil,
+	)
+	namespacePendingPods = prometheus.NewDesc(
+		"kube_binpacking_namespace_pending_pods",
+		"Number of unscheduled pending pods in this namespace",
+		[]string{"namespace"}, nil,
+	)
 	clusterNodeCount = prometheus.NewDesc(
 		"kube_binpacking_cluster_node_count",
 		"Total number of nodes in the cluster",
@@ -104,6 +495,41 @@ var (
 		"Time since last informer cache sync",
 		nil, nil,
 	)
+	nodeStateInfo = prometheus.NewDesc(
+		"kube_binpacking_node_state",
+		"State of this node (always 1): schedulable, cordoned, not_ready or deleting",
+		[]string{"node", "node_state"}, nil,
+	)
+	clusterNodeStateCount = prometheus.NewDesc(
+		"kube_binpacking_cluster_node_state_count",
+		"Number of nodes in the cluster by node state",
+		[]string{"node_state"}, nil,
+	)
+	groupNodeStateCount = prometheus.NewDesc(
+		"kube_binpacking_group_node_state_count",
+		"Number of nodes in this label group by node state",
+		[]string{"label_group", "label_group_value", "node_state"}, nil,
+	)
+	clusterNodeStateAllocated = prometheus.NewDesc(
+		"kube_binpacking_cluster_node_state_allocated",
+		"Total resource requested by pods on nodes in this node state across the cluster",
+		[]string{"resource", "node_state"}, nil,
+	)
+	clusterNodeStateAllocatable = prometheus.NewDesc(
+		"kube_binpacking_cluster_node_state_allocatable",
+		"Total allocatable resource of nodes in this node state across the cluster",
+		[]string{"resource", "node_state"}, nil,
+	)
+	groupNodeStateAllocated = prometheus.NewDesc(
+		"kube_binpacking_group_node_state_allocated",
+		"Total resource requested by pods on nodes in this label group and node state",
+		[]string{"label_group", "label_group_value", "resource", "node_state"}, nil,
+	)
+	groupNodeStateAllocatable = prometheus.NewDesc(
+		"kube_binpacking_group_node_state_allocatable",
+		"Total allocatable resource of nodes in this label group and node state",
+		[]string{"label_group", "label_group_value", "resource", "node_state"}, nil,
+	)
 	leaderStatus = prometheus.NewDesc(
 		"kube_binpacking_leader_status",
 		"Whether this instance is the leader (1) or standby (0). Only present when leader election is enabled",
@@ -111,6 +537,270 @@ var (
 	)
 )
 
+// In-place pod resize states, used as values of the resize_state label.
+const (
+	resizeStateInProgress = "in_progress"
+	resizeStateDeferred   = "deferred"
+	resizeStateInfeasible = "infeasible"
+)
+
+var resizeStates = []string{resizeStateInProgress, resizeStateDeferred, resizeStateInfeasible}
+
+// Node states, used as values of the node_state label. A node in any state
+// but schedulable cannot take new pods.
+const (
+	nodeStateSchedulable = "schedulable"
+	nodeStateCordoned    = "cordoned"
+	nodeStateNotReady    = "not_ready"
+	nodeStateDeleting    = "deleting"
+)
+
+var nodeStates = []string{nodeStateSchedulable, nodeStateCordoned, nodeStateNotReady, nodeStateDeleting}
+
+// Workload kinds, classified by the pod's controlling owner. Deployment pods
+// are owned by a ReplicaSet and CronJob pods by a Job.
+const (
+	workloadDaemonSet = iota
+	workloadReplicaSet
+	workloadStatefulSet
+	workloadJob
+	workloadStatic
+	workloadBare
+	workloadOther
+	numWorkloadKinds
+)
+
+// workloadKindNames are the values of the workload_kind label, indexed by
+// workload kind.
+var workloadKindNames = [numWorkloadKinds]string{
+	workloadDaemonSet:   "daemonset",
+	workloadReplicaSet:  "replicaset",
+	workloadStatefulSet: "statefulset",
+	workloadJob:         "job",
+	workloadStatic:      "static",
+	workloadBare:        "bare",
+	workloadOther:       "other",
+}
+
+// Pod QoS classes, as assigned by Kubernetes from the cpu and memory
+// requests and limits.
+const (
+	qosGuaranteed = iota
+	qosBurstable
+	qosBestEffort
+	numQOSClasses
+)
+
+// qosClassNames are the values of the qos_class label, indexed by QoS class.
+var qosClassNames = [numQOSClasses]string{
+	qosGuaranteed: "guaranteed",
+	qosBurstable:  "burstable",
+	qosBestEffort: "best_effort",
+}
+
+// priorityClassNone is the priority_class label value of pods without a
+// PriorityClass.
+const priorityClassNone = "<none>"
+
+// namespaceOther is the namespace label value under which namespaces outside
+// the top N are summed. Angle brackets cannot appear in namespace names.
+const namespaceOther = "<other>"
+
+// nodeStateTaints maps the taints set by Kubernetes and node autoscalers to
+// the node state they signal.
+var nodeStateTaints = map[string]string{
+	corev1.TaintNodeUnschedulable:    nodeStateCordoned,
+	corev1.TaintNodeNotReady:         nodeStateNotReady,
+	corev1.TaintNodeUnreachable:      nodeStateNotReady,
+	"ToBeDeletedByClusterAutoscaler": nodeStateDeleting,
+	"karpenter.sh/disrupted":         nodeStateDeleting,
+	"karpenter.sh/disruption":        nodeStateDeleting,
+}
+
+// NodeStateMode selects how nodes that cannot take new pods are aggregated.
+type NodeStateMode string
+
+const (
+	// NodeStateInclude aggregates all nodes regardless of state.
+	NodeStateInclude NodeStateMode = "include"
+	// NodeStateExclude leaves non-schedulable nodes out of the cluster and
+	// label-group aggregates.
+	NodeStateExclude NodeStateMode = "exclude"
+	// NodeStateSeparate excludes non-schedulable nodes like
+	// NodeStateExclude and additionally reports allocated and allocatable
+	// per node state.
+	NodeStateSeparate NodeStateMode = "separate"
+)
+
+// defaultStrandedThreshold is the utilization above which a resource is
+// considered saturated when StrandedThreshold is not set.
+const defaultStrandedThreshold = 0.9
+
+// HistogramMode selects how node utilization distributions are exposed.
+type HistogramMode string
+
+const (
+	HistogramNone    HistogramMode = ""
+	HistogramClassic HistogramMode = "classic"
+	HistogramNative  HistogramMode = "native"
+)
+
+// ConsolidationMode selects which utilization is compared with the
+// consolidation threshold.
+type ConsolidationMode string
+
+const (
+	// ConsolidationDominant compares the node's dominant utilization, like
+	// the Cluster Autoscaler's scale-down utilization check.
+	ConsolidationDominant ConsolidationMode = "dominant"
+	// ConsolidationPerResource compares each tracked resource separately.
+	ConsolidationPerResource ConsolidationMode = "per-resource"
+)
+
+// consolidationDominant is the resource label value of the consolidation
+// metrics in dominant mode.
+const consolidationDominant = "dominant"
+
+// utilizationBuckets are the classic histogram bucket upper bounds for
+// utilization ratios; over-allocated nodes fall into the +Inf bucket.
+var utilizationBuckets = []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9, 1}
+
+// utilizationQuantiles are the quantiles of the per-node utilization ratios
+// reported per label group: min, median, p90 and max.
+var utilizationQuantiles = []float64{0, 0.5, 0.9, 1}
+
+// nativeHistogramSchema is the resolution of native utilization histograms:
+// each power of two is split into 2^3 buckets (~9% wide).
+const nativeHistogramSchema = 3
+
+// resourceAuto, as a tracked resource, expands to every resource found in any
+// node's allocatable.
+const resourceAuto corev1.ResourceName = "auto"
+
+// CollectorOptions enables optional collector features. The zero value
+// emits only the default metric set.
+type CollectorOptions struct {
+	// EnableLimitsMetrics emits the limits_allocated, overcommit_ratio and
+	// pods_without_limits series at every enabled aggregation level.
+	EnableLimitsMetrics bool
+
+	// ResourceAliases are reported as additional resources, see ResourceAlias.
+	ResourceAliases []ResourceAlias
+
+	// EnablePendingByNamespace additionally breaks the pending pod metrics
+	// down by namespace.
+	EnablePendingByNamespace bool
+
+	// StrandedThreshold is the utilization ratio at which a resource counts
+	// as saturated, stranding the free amount of the other resources on the
+	// node. Defaults to defaultStrandedThreshold.
+	StrandedThreshold float64
+
+	// UtilizationHistogram emits the distribution of node utilization
+	// ratios per label group and cluster-wide as a classic or native
+	// histogram, keeping shape information without per-node series.
+	UtilizationHistogram HistogramMode
+
+	// ConsolidationThreshold enables the consolidation metrics: nodes whose
+	// utilization is below it are scale-down candidates. Zero disables them.
+	ConsolidationThreshold float64
+
+	// ConsolidationMode selects the utilization compared with
+	// ConsolidationThreshold. Defaults to ConsolidationDominant.
+	ConsolidationMode ConsolidationMode
+
+	// SimulateConsolidation emits the number of nodes each label group
+	// would need to host its non-DaemonSet pods, see nodesRequired.
+	SimulateConsolidation bool
+
+	// NodeStateMode selects how cordoned, not ready and deleting nodes are
+	// aggregated. Defaults to NodeStateInclude.
+	NodeStateMode NodeStateMode
+
+	// ExcludeTerminatingPods leaves pods with a deletion timestamp out of
+	// allocated and everything derived from it. Their requests are still
+	// reported as terminating_allocated.
+	ExcludeTerminatingPods bool
+
+	// EnableWorkloadKindMetrics breaks allocated down by the kind of
+	// workload owning the pods at every enabled aggregation level.
+	EnableWorkloadKindMetrics bool
+
+	// EnableQOSMetrics breaks allocated and pod counts down by pod QoS
+	// class at every enabled aggregation level.
+	EnableQOSMetrics bool
+
+	// EnableNamespaceAllocation breaks allocated down by namespace per
+	// label group and cluster-wide.
+	EnableNamespaceAllocation bool
+
+	// NamespaceTopN limits the namespace breakdown to the N namespaces with
+	// the largest allocation per resource, summing the rest as <other>.
+	// Zero keeps all namespaces.
+	NamespaceTopN int
+
+	// EnablePriorityClassMetrics breaks allocated down by the pods'
+	// PriorityClass at every enabled aggregation level.
+	EnablePriorityClassMetrics bool
+
+	// PriorityThresholds emits, per label group and cluster-wide, the
+	// allocatable free for pods of at least each priority if all pods of
+	// lower priority were preempted.
+	PriorityThresholds []int32
+
+	// OverheadClasses are reported like the DaemonSet overhead, one
+	// overhead_class series each, see OverheadClass.
+	OverheadClasses []OverheadClass
+}
+
+// OverheadClass is a named set of pods whose requests are reported as
+// overhead, e.g. a "platform" class for kube-system, monitoring and pods
+// labelled platform=true. A pod belongs to the class if it matches any of
+// its matchers. Classes may overlap each other and the DaemonSet and static
+// pod overhead.
+type OverheadClass struct {
+	Name     string
+	Matchers []OverheadMatcher
+}
+
+// OverheadMatcher selects pods by namespace and label selector. A pod must
+// match both; an empty Namespaces or a nil Selector matches every pod.
+type OverheadMatcher struct {
+	Namespaces []string
+	Selector   labels.Selector
+}
+
+func (m OverheadMatcher) matches(pod *corev1.Pod) bool {
+	if len(m.Namespaces) > 0 && !slices.Contains(m.Namespaces, pod.Namespace) {
+		return false
+	}
+	return m.Selector == nil || m.Selector.Matches(labels.Set(pod.Labels))
+}
+
+func (oc OverheadClass) matches(pod *corev1.Pod) bool {
+	for _, m := range oc.Matchers {
+		if m.matches(pod) {
+			return true
+		}
+	}
+	return false
+}
+
+// ResourceAlias is a virtual resource whose requests, limits and allocatable
+// are the weighted sums of its source resources, e.g. a vendor-neutral "gpu"
+// over nvidia.com/gpu and amd.com/gpu, with MIG slices weighted as fractions
+// of a GPU. The source resources are only reported if tracked themselves.
+type ResourceAlias struct {
+	Name    corev1.ResourceName
+	Sources []AliasSource
+}
+
+// AliasSource is a source resource of a ResourceAlias and its weight.
+type AliasSource struct {
+	Resource corev1.ResourceName
+	Weight   float64
+}
+
 // BinpackingCollector implements prometheus.Collector using informer caches.
 type BinpackingCollector struct {
 	nodeLister        listerscorev1.NodeLister
@@ -121,50 +811,273 @@ type BinpackingCollector struct {
 	enableNodeMetrics bool
 	syncInfo          *SyncInfo
 	isLeader          *atomic.Bool // nil = leader election disabled (always emit); non-nil = check value
+	opts              CollectorOptions
+	aliases           map[corev1.ResourceName]ResourceAlias
+	shadowingAliases  sync.Map // alias names already warned about, see warnShadowingAliases
 }
 
-// calculatePodRequest computes the effective resource request for a pod.
-// Kubernetes reserves the max of:
-// 1. Sum of all regular container requests
-// 2. Highest init container request (they run sequentially)
-func calculatePodRequest(pod *corev1.Pod, resource corev1.ResourceName) (float64, podRequestDetails) {
+// containerValueFunc returns a container's value for the resource being
+// aggregated and whether the container declares one. status is nil when the
+// kubelet reports no status for the container.
+type containerValueFunc func(container corev1.Container, status *corev1.ContainerStatus) (float64, bool)
+
+// aggregateContainers walks the pod's containers following the scheduler's
+// accounting:
+//  1. Regular containers run concurrently, so their values are summed.
+//  2. Restartable init containers (native sidecars, restartPolicy: Always)
+//     keep running once started, so their values add to that sum.
+//  3. Regular init containers run sequentially; each one needs its own
+//     value plus the sidecars started before it.
+//
+// The caller combines (1 + 2) and the largest step of (3) into the effective
+// pod value.
+func aggregateContainers(pod *corev1.Pod, value containerValueFunc) podRequestDetails {
 	details := podRequestDetails{}
+	statuses := containerStatusesByName(pod)
 
-	// Sum regular container requests
-	var regularSum float64
 	for _, container := range pod.Spec.Containers {
-		if req, ok := container.Resources.Requests[resource]; ok {
-			val := req.AsApproximateFloat64()
-			regularSum += val
+		if val, ok := value(container, statuses[container.Name]); ok {
+			details.regularSum += val
 			details.containerCount++
 		}
 	}
-	details.regularSum = regularSum
 
-	// Find max init container request
-	var initMax float64
-	var initMaxContainer string
+	// Walk init containers in order. Sidecars accumulate into sidecarSum;
+	// every init step needs the sidecars started so far plus its own value.
 	for _, container := range pod.Spec.InitContainers {
-		if req, ok := container.Resources.Requests[resource]; ok {
-			val := req.AsApproximateFloat64()
-			if val > initMax {
-				initMax = val
-				initMaxContainer = container.Name
+		var step float64
+		if isRestartableInitContainer(container) {
+			// Only sidecars can be resized; regular init containers have
+			// already completed by the time a resize happens.
+			val, ok := value(container, statuses[container.Name])
+			details.sidecarSum += val
+			step = details.sidecarSum
+			if ok {
+				details.sidecarCount++
+			}
+		} else {
+			val, ok := value(container, nil)
+			step = details.sidecarSum + val
+			if ok {
+				details.initContainerCount++
 			}
-			details.initContainerCount++
+		}
+
+		if step > details.initMax {
+			details.initMax = step
+			details.initMaxContainer = container.Name
 		}
 	}
-	details.initMax = initMax
-	details.initMaxContainer = initMaxContainer
 
-	// Return the maximum
-	if initMax > regularSum {
-		details.effective = initMax
+	return details
+}
+
+// calculatePodRequest computes the effective resource request for a pod. The
+// pod reserves the max of its running containers and the largest init step
+// (see aggregateContainers), plus the RuntimeClass pod overhead
+// (spec.overhead), if any. When pod-level requests (spec.resources,
+// PodLevelResources feature) are set for the resource, they replace the
+// container math. Container requests are resize-aware, see containerRequest.
+//
+// The "pods" resource is not requested by containers: every pod occupies one
+// of the node's pod slots.
+func calculatePodRequest(pod *corev1.Pod, resource corev1.ResourceName) (float64, podRequestDetails) {
+	if resource == corev1.ResourcePods {
+		return 1, podRequestDetails{effective: 1}
+	}
+
+	resizeInfeasible := podResizeState(pod) == resizeStateInfeasible
+	details := aggregateContainers(pod, func(container corev1.Container, status *corev1.ContainerStatus) (float64, bool) {
+		return containerRequest(container, status, resizeInfeasible, resource)
+	})
+
+	// Take the maximum, unless the pod declares a pod-level request for this
+	// resource, which the scheduler uses instead of the container requests.
+	effective := details.regularSum + details.sidecarSum
+	if req, ok := podLevelRequest(pod, resource); ok {
+		effective = req
+		details.podLevel = true
+	} else if details.initMax > effective {
+		effective = details.initMax
 		details.usedInit = true
-		return initMax, details
 	}
-	details.effective = regularSum
-	return regularSum, details
+
+	// The scheduler charges RuntimeClass overhead on top of the container
+	// requests for the whole lifetime of the pod.
+	if qty, ok := pod.Spec.Overhead[resource]; ok {
+		details.overhead = qty.AsApproximateFloat64()
+		effective += details.overhead
+	}
+
+	details.effective = effective
+	return effective, details
+}
+
+// calculatePodLimit computes the effective resource limit for a pod with the
+// same container rules as calculatePodRequest. Pod-level limits replace the
+// container math, and the pod overhead is only added to a non-zero limit.
+//
+// bounded is false when a container sets no limit for a resource it may
+// consume without one (see isLimitRequired): such a pod can grow up to the
+// node's capacity and the returned limit is only a lower bound.
+func calculatePodLimit(pod *corev1.Pod, resource corev1.ResourceName) (limit float64, bounded bool) {
+	if resource == corev1.ResourcePods {
+		return 1, true
+	}
+
+	resizeInfeasible := podResizeState(pod) == resizeStateInfeasible
+	bounded = true
+	details := aggregateContainers(pod, func(container corev1.Container, status *corev1.ContainerStatus) (float64, bool) {
+		val, ok := containerLimit(container, status, resizeInfeasible, resource)
+		if !ok && isLimitRequired(resource) {
+			bounded = false
+		}
+		return val, ok
+	})
+
+	limit = max(details.regularSum+details.sidecarSum, details.initMax)
+	if lim, ok := podLevelLimit(pod, resource); ok {
+		limit = lim
+		bounded = true
+	}
+
+	if qty, ok := pod.Spec.Overhead[resource]; ok && limit > 0 {
+		limit += qty.AsApproximateFloat64()
+	}
+	return limit, bounded
+}
+
+// containerRequest returns the container's request for the resource and
+// whether any request was found. When the kubelet reports actuated resources
+// (in-place pod resize), the scheduler reserves the larger of the desired
+// (spec), actuated (status.resources) and allocated requests, so capacity is
+// held for both sides of an outstanding resize. If the resize is infeasible
+// the desired request will never be actuated and is ignored.
+func containerRequest(container corev1.Container, status *corev1.ContainerStatus, resizeInfeasible bool, resource corev1.ResourceName) (float64, bool) {
+	if status == nil || status.Resources == nil {
+		return maxQuantity(resource, container.Resources.Requests)
+	}
+	if resizeInfeasible {
+		return maxQuantity(resource, status.Resources.Requests, status.AllocatedResources)
+	}
+	return maxQuantity(resource, container.Resources.Requests, status.Resources.Requests, status.AllocatedResources)
+}
+
+// containerLimit returns the container's limit for the resource and whether
+// any limit was found, resize-aware like containerRequest. The kubelet does
+// not report allocated limits, so only the desired and actuated limits count.
+func containerLimit(container corev1.Container, status *corev1.ContainerStatus, resizeInfeasible bool, resource corev1.ResourceName) (float64, bool) {
+	if status == nil || status.Resources == nil {
+		return maxQuantity(resource, container.Resources.Limits)
+	}
+	if resizeInfeasible {
+		return maxQuantity(resource, status.Resources.Limits)
+	}
+	return maxQuantity(resource, container.Resources.Limits, status.Resources.Limits)
+}
+
+// maxQuantity returns the largest value of the resource across the lists and
+// whether any list contains it.
+func maxQuantity(resource corev1.ResourceName, lists ...corev1.ResourceList) (float64, bool) {
+	var val float64
+	var found bool
+	for _, list := range lists {
+		if qty, ok := list[resource]; ok {
+			val = max(val, qty.AsApproximateFloat64())
+			found = true
+		}
+	}
+	return val, found
+}
+
+// isLimitRequired returns true if a container without a limit for the resource
+// may use as much of it as the node has. Extended resources and hugepages
+// cannot be overcommitted, so a container without a limit gets none of them.
+func isLimitRequired(resource corev1.ResourceName) bool {
+	return resource == corev1.ResourceCPU ||
+		resource == corev1.ResourceMemory ||
+		resource == corev1.ResourceEphemeralStorage
+}
+
+// containerStatusesByName indexes the pod's container and init container
+// statuses by container name.
+func containerStatusesByName(pod *corev1.Pod) map[string]*corev1.ContainerStatus {
+	if len(pod.Status.ContainerStatuses) == 0 && len(pod.Status.InitContainerStatuses) == 0 {
+		return nil
+	}
+	statuses := make(map[string]*corev1.ContainerStatus, len(pod.Status.ContainerStatuses)+len(pod.Status.InitContainerStatuses))
+	for i := range pod.Status.ContainerStatuses {
+		statuses[pod.Status.ContainerStatuses[i].Name] = &pod.Status.ContainerStatuses[i]
+	}
+	for i := range pod.Status.InitContainerStatuses {
+		statuses[pod.Status.InitContainerStatuses[i].Name] = &pod.Status.InitContainerStatuses[i]
+	}
+	return statuses
+}
+
+// podResizeState returns the in-place resize state of the pod derived from its
+// PodResizePending and PodResizeInProgress conditions, or "" if no resize is
+// outstanding. A pending resize takes precedence over one in progress.
+func podResizeState(pod *corev1.Pod) string {
+	var state string
+	for _, cond := range pod.Status.Conditions {
+		if cond.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch cond.Type {
+		case corev1.PodResizePending:
+			switch cond.Reason {
+			case corev1.PodReasonInfeasible:
+				return resizeStateInfeasible
+			case corev1.PodReasonDeferred:
+				state = resizeStateDeferred
+			}
+		case corev1.PodResizeInProgress:
+			if state == "" {
+				state = resizeStateInProgress
+			}
+		}
+	}
+	return state
+}
+
+// podLevelRequest returns the pod-level request (spec.resources.requests) for
+// the resource. Like the scheduler, only cpu, memory and hugepages are honoured
+// at pod level; other resources always use the container requests.
+func podLevelRequest(pod *corev1.Pod, resource corev1.ResourceName) (float64, bool) {
+	if pod.Spec.Resources == nil || !isSupportedPodLevelResource(resource) {
+		return 0, false
+	}
+	req, ok := pod.Spec.Resources.Requests[resource]
+	if !ok {
+		return 0, false
+	}
+	return req.AsApproximateFloat64(), true
+}
+
+// podLevelLimit returns the pod-level limit (spec.resources.limits) for the
+// resource, with the same resource restrictions as podLevelRequest.
+func podLevelLimit(pod *corev1.Pod, resource corev1.ResourceName) (float64, bool) {
+	if pod.Spec.Resources == nil || !isSupportedPodLevelResource(resource) {
+		return 0, false
+	}
+	limit, ok := pod.Spec.Resources.Limits[resource]
+	if !ok {
+		return 0, false
+	}
+	return limit.AsApproximateFloat64(), true
+}
+
+func isSupportedPodLevelResource(resource corev1.ResourceName) bool {
+	return resource == corev1.ResourceCPU ||
+		resource == corev1.ResourceMemory ||
+		strings.HasPrefix(string(resource), corev1.ResourceHugePagesPrefix)
+}
+
+// isRestartableInitContainer returns true if the init container is a native
+// sidecar, i.e. it has restartPolicy: Always (Kubernetes >= 1.29).
+func isRestartableInitContainer(container corev1.Container) bool {
+	return container.RestartPolicy != nil && *container.RestartPolicy == corev1.ContainerRestartPolicyAlways
 }
 
 // isDaemonSetPod returns true if the pod is owned by a DaemonSet.
@@ -179,14 +1092,246 @@ func isDaemonSetPod(pod *corev1.Pod) bool {
 	return false
 }
 
-type podRequestDetails struct {
-	regularSum         float64
-	initMax            float64
-	effective          float64
-	containerCount     int
-	initContainerCount int
-	initMaxContainer   string
-	usedInit           bool
+// isStaticPod returns true if the pod is the mirror of a static pod, which
+// the kubelet runs from its manifest directory. Mirror pods are owned by
+// their Node and carry the config.mirror annotation.
+func isStaticPod(pod *corev1.Pod) bool {
+	if _, ok := pod.Annotations[corev1.MirrorPodAnnotationKey]; ok {
+		return true
+	}
+	for _, ref := range pod.OwnerReferences {
+		if ref.Kind == "Node" {
+			return true
+		}
+	}
+	return false
+}
+
+// podWorkloadKind classifies the pod by its controlling owner reference, or
+// its first owner if none is marked as controller. Static pods are
+// classified by isStaticPod.
+func podWorkloadKind(pod *corev1.Pod) int {
+	if isStaticPod(pod) {
+		return workloadStatic
+	}
+	if len(pod.OwnerReferences) == 0 {
+		return workloadBare
+	}
+	owner := pod.OwnerReferences[0]
+	for _, ref := range pod.OwnerReferences {
+		if ref.Controller != nil && *ref.Controller {
+			owner = ref
+			break
+		}
+	}
+	switch owner.Kind {
+	case "DaemonSet":
+		return workloadDaemonSet
+	case "ReplicaSet":
+		return workloadReplicaSet
+	case "StatefulSet":
+		return workloadStatefulSet
+	case "Job":
+		return workloadJob
+	case "Node":
+		return workloadStatic
+	default:
+		return workloadOther
+	}
+}
+
+// podQOSClass returns the pod's QoS class as reported in its status. Pods
+// without one, e.g. not yet admitted, are classified from the cpu and memory
+// requests and limits of their containers, or of the pod if it sets
+// pod-level resources: BestEffort without any, Guaranteed if every
+// container limits both and requests equal limits, Burstable otherwise.
+func podQOSClass(pod *corev1.Pod) int {
+	switch pod.Status.QOSClass {
+	case corev1.PodQOSGuaranteed:
+		return qosGuaranteed
+	case corev1.PodQOSBurstable:
+		return qosBurstable
+	case corev1.PodQOSBestEffort:
+		return qosBestEffort
+	}
+
+	var requirements []corev1.ResourceRequirements
+	if pod.Spec.Resources != nil {
+		requirements = append(requirements, *pod.Spec.Resources)
+	} else {
+		for _, c := range pod.Spec.InitContainers {
+			requirements = append(requirements, c.Resources)
+		}
+		for _, c := range pod.Spec.Containers {
+			requirements = append(requirements, c.Resources)
+		}
+	}
+
+	declared, guaranteed := false, true
+	for _, r := range requirements {
+		for _, res := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+			request, hasRequest := r.Requests[res]
+			limit, hasLimit := r.Limits[res]
+			hasRequest = hasRequest && !request.IsZero()
+			hasLimit = hasLimit && !limit.IsZero()
+			if hasRequest || hasLimit {
+				declared = true
+			}
+			// An unset request defaults to the limit.
+			if !hasLimit || (hasRequest && request.Cmp(limit) != 0) {
+				guaranteed = false
+			}
+		}
+	}
+	switch {
+	case !declared:
+		return qosBestEffort
+	case guaranteed:
+		return qosGuaranteed
+	default:
+		return qosBurstable
+	}
+}
+
+// podPriority returns the pod's priority, resolved from its PriorityClass
+// at admission. Pods admitted without one have priority zero.
+func podPriority(pod *corev1.Pod) int32 {
+	if pod.Spec.Priority == nil {
+		return 0
+	}
+	return *pod.Spec.Priority
+}
+
+// podPriorityClass returns the name of the pod's PriorityClass, or
+// priorityClassNone.
+func podPriorityClass(pod *corev1.Pod) string {
+	if pod.Spec.PriorityClassName == "" {
+		return priorityClassNone
+	}
+	return pod.Spec.PriorityClassName
+}
+
+type podRequestDetails struct {
+	regularSum         float64
+	sidecarSum         float64
+	initMax            float64
+	overhead           float64
+	effective          float64
+	containerCount     int
+	initContainerCount int
+	sidecarCount       int
+	initMaxContainer   string
+	usedInit           bool
+	podLevel           bool
+}
+
+// resourceUsage holds the accounting for a single resource, either on one node
+// or summed over a set of nodes.
+type resourceUsage struct {
+	allocated         float64
+	allocatable       float64
+	capacity          float64
+	stranded          float64
+	daemonsetOverhead float64
+	staticPodOverhead float64
+	podOverhead       float64
+	terminating       float64 // requests of pods with a deletion timestamp
+	limitsAllocated   float64 // only computed with EnableLimitsMetrics
+	podsWithoutLimits int     // only computed with EnableLimitsMetrics
+
+	// Allocated by workload kind, only computed with
+	// EnableWorkloadKindMetrics.
+	workloadAllocated [numWorkloadKinds]float64
+
+	// Allocated by pod QoS class, only computed with EnableQOSMetrics.
+	qosAllocated [numQOSClasses]float64
+
+	// Allocatable minus the requests of pods of at least each priority,
+	// floored at zero per node and indexed like PriorityThresholds. Nil
+	// without priority thresholds.
+	priorityFree []float64
+
+	// Requests of pods in each overhead class, indexed like
+	// OverheadClasses. Nil without overhead classes.
+	classOverhead []float64
+}
+
+func (u *resourceUsage) add(o resourceUsage) {
+	u.allocated += o.allocated
+	u.allocatable += o.allocatable
+	u.capacity += o.capacity
+	u.stranded += o.stranded
+	u.daemonsetOverhead += o.daemonsetOverhead
+	u.staticPodOverhead += o.staticPodOverhead
+	u.podOverhead += o.podOverhead
+	u.terminating += o.terminating
+	u.limitsAllocated += o.limitsAllocated
+	for kind := range o.workloadAllocated {
+		u.workloadAllocated[kind] += o.workloadAllocated[kind]
+	}
+	for class := range o.qosAllocated {
+		u.qosAllocated[class] += o.qosAllocated[class]
+	}
+	u.classOverhead = addSlice(u.classOverhead, o.classOverhead)
+	u.priorityFree = addSlice(u.priorityFree, o.priorityFree)
+	u.podsWithoutLimits += o.podsWithoutLimits
+}
+
+// addSlice adds o to s element-wise, allocating s on first use.
+func addSlice(s, o []float64) []float64 {
+	if o != nil && s == nil {
+		s = make([]float64, len(o))
+	}
+	for i, v := range o {
+		s[i] += v
+	}
+	return s
+}
+
+// sliceValue returns s[i], or zero if s is nil, e.g. in a sum over no nodes.
+func sliceValue(s []float64, i int) float64 {
+	if s == nil {
+		return 0
+	}
+	return s[i]
+}
+
+// reservedRatio returns the share of capacity held back from pods by
+// kube-reserved, system-reserved and eviction thresholds.
+func (u resourceUsage) reservedRatio() float64 {
+	return safeRatio(u.capacity-u.allocatable, u.capacity)
+}
+
+// nodeUsage holds the per-resource accounting of a single node, computed once
+// per scrape and reused by the cluster and label-group aggregations.
+type nodeUsage struct {
+	node         *corev1.Node
+	resources    map[corev1.ResourceName]resourceUsage
+	resizingPods map[string]int     // keyed by resize state
+	qosPods      [numQOSClasses]int // only counted with EnableQOSMetrics
+	state        string             // one of nodeStates
+
+	// Dominant resource: the tracked resource with the highest utilization.
+	// Unset if the node has no allocatable for any tracked resource.
+	dominantResource corev1.ResourceName
+	dominantRatio    float64
+
+	// Consolidation candidacy keyed by the consolidation resource label.
+	// Only set when consolidation metrics are enabled and the node has
+	// allocatable for the compared resource.
+	underutilized map[string]bool
+
+	// Allocated per resource and namespace, only recorded with
+	// EnableNamespaceAllocation.
+	namespaceAllocated map[corev1.ResourceName]map[string]float64
+
+	// Allocated per resource and PriorityClass, only recorded with
+	// EnablePriorityClassMetrics.
+	priorityClassAllocated map[corev1.ResourceName]map[string]float64
+
+	// Requests of each pod on the node other than DaemonSet and static
+	// pods, only recorded for the consolidation simulation.
+	workloadRequests []map[corev1.ResourceName]float64
 }
 
 func NewBinpackingCollector(
@@ -198,7 +1343,31 @@ func NewBinpackingCollector(
 	enableNodeMetrics bool,
 	syncInfo *SyncInfo,
 	isLeader *atomic.Bool,
+	opts CollectorOptions,
 ) *BinpackingCollector {
+	if opts.StrandedThreshold <= 0 {
+		opts.StrandedThreshold = defaultStrandedThreshold
+	}
+	if opts.ConsolidationMode == "" {
+		opts.ConsolidationMode = ConsolidationDominant
+	}
+	if opts.NodeStateMode == "" {
+		opts.NodeStateMode = NodeStateInclude
+	}
+
+	// Aliases are always reported, so track them like configured resources.
+	var aliases map[corev1.ResourceName]ResourceAlias
+	if len(opts.ResourceAliases) > 0 {
+		aliases = make(map[corev1.ResourceName]ResourceAlias, len(opts.ResourceAliases))
+		resources = slices.Clone(resources)
+		for _, alias := range opts.ResourceAliases {
+			aliases[alias.Name] = alias
+			if !slices.Contains(resources, alias.Name) {
+				resources = append(resources, alias.Name)
+			}
+		}
+	}
+
 	return &BinpackingCollector{
 		nodeLister:        nodeLister,
 		podLister:         podLister,
@@ -208,6 +1377,8 @@ func NewBinpackingCollector(
 		enableNodeMetrics: enableNodeMetrics,
 		syncInfo:          syncInfo,
 		isLeader:          isLeader,
+		opts:              opts,
+		aliases:           aliases,
 	}
 }
 
@@ -218,20 +1389,160 @@ func (c *BinpackingCollector) Describe(ch chan<- *prometheus.Desc) {
 		ch <- nodeUtilization
 		ch <- nodeDaemonsetOverhead
 		ch <- nodeDaemonsetOverheadRatio
+		ch <- nodeStaticPodOverhead
+		ch <- nodeStaticPodOverheadRatio
+		if len(c.opts.OverheadClasses) > 0 {
+			ch <- nodeClassOverhead
+			ch <- nodeClassOverheadRatio
+		}
+		ch <- nodePodOverhead
+		ch <- nodeTerminatingAllocated
+		ch <- nodeResizingPods
+		ch <- nodeCapacity
+		ch <- nodeReservedRatio
+		ch <- nodeStranded
+		ch <- nodeStrandedRatio
+		ch <- nodeDominantUtilization
+		ch <- nodeStateInfo
+		if c.opts.ConsolidationThreshold > 0 {
+			ch <- nodeConsolidationCandidate
+		}
+		if c.opts.EnableWorkloadKindMetrics {
+			ch <- nodeWorkloadAllocated
+		}
+		if c.opts.EnableQOSMetrics {
+			ch <- nodeQOSAllocated
+			ch <- nodeQOSPods
+		}
+		if c.opts.EnablePriorityClassMetrics {
+			ch <- nodePriorityClassAllocated
+		}
+		if c.opts.EnableLimitsMetrics {
+			ch <- nodeLimitsAllocated
+			ch <- nodeOvercommitRatio
+			ch <- nodePodsWithoutLimits
+		}
 	}
 	ch <- clusterAllocated
 	ch <- clusterAllocatable
 	ch <- clusterUtilization
 	ch <- clusterDaemonsetOverhead
 	ch <- clusterDaemonsetOverheadRatio
+	ch <- clusterStaticPodOverhead
+	ch <- clusterStaticPodOverheadRatio
+	if len(c.opts.OverheadClasses) > 0 {
+		ch <- clusterClassOverhead
+		ch <- clusterClassOverheadRatio
+	}
+	ch <- clusterPodOverhead
+	ch <- clusterTerminatingAllocated
+	if c.opts.EnableNamespaceAllocation {
+		ch <- clusterNamespaceAllocated
+	}
+	ch <- clusterResizingPods
+	ch <- clusterCapacity
+	ch <- clusterReservedRatio
+	ch <- clusterStranded
+	ch <- clusterStrandedRatio
+	ch <- clusterDominantUtilization
+	if c.opts.UtilizationHistogram != HistogramNone {
+		ch <- clusterNodeUtilizationHistogram
+	}
+	ch <- clusterLargestFree
+	ch <- clusterFragmentationIndex
+	if c.opts.EnableWorkloadKindMetrics {
+		ch <- clusterWorkloadAllocated
+	}
+	if c.opts.EnableQOSMetrics {
+		ch <- clusterQOSAllocated
+		ch <- clusterQOSPods
+	}
+	if c.opts.EnablePriorityClassMetrics {
+		ch <- clusterPriorityClassAllocated
+	}
+	if len(c.opts.PriorityThresholds) > 0 {
+		ch <- clusterPriorityEffectiveFree
+	}
+	if c.opts.EnableLimitsMetrics {
+		ch <- clusterLimitsAllocated
+		ch <- clusterOvercommitRatio
+		ch <- clusterPodsWithoutLimits
+	}
+	if c.opts.ConsolidationThreshold > 0 {
+		ch <- clusterUnderutilizedNodes
+	}
 	ch <- clusterNodeCount
+	ch <- clusterNodeStateCount
+	if c.opts.NodeStateMode == NodeStateSeparate {
+		ch <- clusterNodeStateAllocated
+		ch <- clusterNodeStateAllocatable
+	}
+	ch <- pendingRequested
+	ch <- pendingPods
+	ch <- pendingOldestAge
+	if c.opts.EnablePendingByNamespace {
+		ch <- namespacePendingRequested
+		ch <- namespacePendingPods
+	}
 	if len(c.labelGroups) > 0 {
 		ch <- groupAllocated
 		ch <- groupAllocatable
 		ch <- groupUtilization
 		ch <- groupDaemonsetOverhead
 		ch <- groupDaemonsetOverheadRatio
+		ch <- groupStaticPodOverhead
+		ch <- groupStaticPodOverheadRatio
+		if len(c.opts.OverheadClasses) > 0 {
+			ch <- groupClassOverhead
+			ch <- groupClassOverheadRatio
+		}
+		ch <- groupPodOverhead
+		ch <- groupTerminatingAllocated
+		if c.opts.EnableNamespaceAllocation {
+			ch <- groupNamespaceAllocated
+		}
+		ch <- groupCapacity
+		ch <- groupReservedRatio
+		ch <- groupStranded
+		ch <- groupStrandedRatio
+		ch <- groupDominantUtilization
+		ch <- groupNodeUtilizationQuantile
+		ch <- groupNodeUtilizationStddev
+		if c.opts.UtilizationHistogram != HistogramNone {
+			ch <- groupNodeUtilizationHistogram
+		}
+		ch <- groupLargestFree
+		ch <- groupFragmentationIndex
 		ch <- groupNodeCount
+		ch <- groupNodeStateCount
+		if c.opts.NodeStateMode == NodeStateSeparate {
+			ch <- groupNodeStateAllocated
+			ch <- groupNodeStateAllocatable
+		}
+		if c.opts.SimulateConsolidation {
+			ch <- groupNodesRequired
+		}
+		if c.opts.ConsolidationThreshold > 0 {
+			ch <- groupUnderutilizedNodes
+		}
+		if c.opts.EnableWorkloadKindMetrics {
+			ch <- groupWorkloadAllocated
+		}
+		if c.opts.EnableQOSMetrics {
+			ch <- groupQOSAllocated
+			ch <- groupQOSPods
+		}
+		if c.opts.EnablePriorityClassMetrics {
+			ch <- groupPriorityClassAllocated
+		}
+		if len(c.opts.PriorityThresholds) > 0 {
+			ch <- groupPriorityEffectiveFree
+		}
+		if c.opts.EnableLimitsMetrics {
+			ch <- groupLimitsAllocated
+			ch <- groupOvercommitRatio
+			ch <- groupPodsWithoutLimits
+		}
 	}
 	ch <- cacheAge
 	if c.isLeader != nil {
@@ -255,258 +1566,1343 @@ func (c *BinpackingCollector) Collect(ch chan<- prometheus.Metric) {
 		}
 	}
 
-	nodes, err := c.nodeLister.List(labels.Everything())
-	if err != nil {
-		c.logger.Error("failed to list nodes", "error", err)
-		return
+	nodes, err := c.nodeLister.List(labels.Everything())
+	if err != nil {
+		c.logger.Error("failed to list nodes", "error", err)
+		return
+	}
+
+	pods, err := c.podLister.List(labels.Everything())
+	if err != nil {
+		c.logger.Error("failed to list pods", "error", err)
+		return
+	}
+
+	c.warnShadowingAliases(nodes)
+	resources := c.resolveResources(nodes)
+	c.logger.Debug("scraping metrics", "node_count", len(nodes), "pod_count", len(pods), "resources", resources)
+
+	// Build podsByNode map, filtering out unscheduled and terminated pods.
+	// Unscheduled pods that have not terminated are pending demand.
+	podsByNode := make(map[string][]*corev1.Pod)
+	var pending []*corev1.Pod
+	var unscheduledCount, terminatedCount int
+	for _, pod := range pods {
+		if pod.Spec.NodeName == "" {
+			unscheduledCount++
+			c.logger.Debug("skipping unscheduled pod", "pod", pod.Namespace+"/"+pod.Name)
+			if pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed {
+				pending = append(pending, pod)
+			}
+			continue
+		}
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			terminatedCount++
+			c.logger.Debug("skipping terminated pod", "pod", pod.Namespace+"/"+pod.Name, "phase", pod.Status.Phase)
+			continue
+		}
+		podsByNode[pod.Spec.NodeName] = append(podsByNode[pod.Spec.NodeName], pod)
+	}
+
+	if unscheduledCount > 0 || terminatedCount > 0 {
+		c.logger.Debug("filtered pods", "unscheduled", unscheduledCount, "terminated", terminatedCount)
+	}
+
+	// Compute per-node accounting once. Cluster and label-group aggregates
+	// are sums over these, so pod requests are only evaluated once per scrape.
+	usages := make([]*nodeUsage, 0, len(nodes))
+	for _, node := range nodes {
+		usage := c.computeNodeUsage(node, podsByNode[node.Name], resources)
+		usages = append(usages, usage)
+
+		// Emit per-node metrics if enabled
+		if c.enableNodeMetrics {
+			c.emitNodeMetrics(ch, usage, resources)
+		}
+	}
+
+	// Emit cluster-aggregate metrics. Nodes that cannot take new pods are
+	// left out unless NodeStateMode includes them.
+	aggregated := c.aggregatedUsages(usages)
+	clusterTotals := sumNodeUsage(aggregated)
+	for _, res := range resources {
+		resStr := string(res)
+		u := clusterTotals[res]
+		ratio := safeRatio(u.allocated, u.allocatable)
+		dsRatio := safeRatio(u.daemonsetOverhead, u.allocatable)
+
+		c.logger.Debug("cluster metrics",
+			"resource", resStr,
+			"allocated", u.allocated,
+			"allocatable", u.allocatable,
+			"utilization", ratio,
+			"daemonset_overhead", u.daemonsetOverhead,
+			"pod_overhead", u.podOverhead)
+
+		ch <- prometheus.MustNewConstMetric(clusterAllocated, prometheus.GaugeValue, u.allocated, resStr)
+		ch <- prometheus.MustNewConstMetric(clusterAllocatable, prometheus.GaugeValue, u.allocatable, resStr)
+		ch <- prometheus.MustNewConstMetric(clusterUtilization, prometheus.GaugeValue, ratio, resStr)
+		ch <- prometheus.MustNewConstMetric(clusterDaemonsetOverhead, prometheus.GaugeValue, u.daemonsetOverhead, resStr)
+		ch <- prometheus.MustNewConstMetric(clusterDaemonsetOverheadRatio, prometheus.GaugeValue, dsRatio, resStr)
+		ch <- prometheus.MustNewConstMetric(clusterStaticPodOverhead, prometheus.GaugeValue, u.staticPodOverhead, resStr)
+		ch <- prometheus.MustNewConstMetric(clusterStaticPodOverheadRatio, prometheus.GaugeValue, safeRatio(u.staticPodOverhead, u.allocatable), resStr)
+		for i, class := range c.opts.OverheadClasses {
+			ch <- prometheus.MustNewConstMetric(clusterClassOverhead, prometheus.GaugeValue, sliceValue(u.classOverhead, i), class.Name, resStr)
+			ch <- prometheus.MustNewConstMetric(clusterClassOverheadRatio, prometheus.GaugeValue, safeRatio(sliceValue(u.classOverhead, i), u.allocatable), class.Name, resStr)
+		}
+		ch <- prometheus.MustNewConstMetric(clusterPodOverhead, prometheus.GaugeValue, u.podOverhead, resStr)
+		ch <- prometheus.MustNewConstMetric(clusterTerminatingAllocated, prometheus.GaugeValue, u.terminating, resStr)
+		ch <- prometheus.MustNewConstMetric(clusterCapacity, prometheus.GaugeValue, u.capacity, resStr)
+		ch <- prometheus.MustNewConstMetric(clusterReservedRatio, prometheus.GaugeValue, u.reservedRatio(), resStr)
+		ch <- prometheus.MustNewConstMetric(clusterStranded, prometheus.GaugeValue, u.stranded, resStr)
+		ch <- prometheus.MustNewConstMetric(clusterStrandedRatio, prometheus.GaugeValue, safeRatio(u.stranded, u.allocatable), resStr)
+
+		frag := computeFragmentation(aggregated, res)
+		ch <- prometheus.MustNewConstMetric(clusterLargestFree, prometheus.GaugeValue, frag.largestFree, resStr)
+		ch <- prometheus.MustNewConstMetric(clusterFragmentationIndex, prometheus.GaugeValue, frag.index(), resStr)
+
+		if c.opts.UtilizationHistogram != HistogramNone {
+			ch <- c.utilizationHistogram(clusterNodeUtilizationHistogram, aggregated, res, resStr)
+		}
+
+		if c.opts.EnableNamespaceAllocation {
+			for _, ns := range topNamespaces(aggregated, res, c.opts.NamespaceTopN) {
+				ch <- prometheus.MustNewConstMetric(clusterNamespaceAllocated, prometheus.GaugeValue, ns.allocated, ns.namespace, resStr)
+			}
+		}
+
+		if c.opts.EnableWorkloadKindMetrics {
+			for kind, name := range workloadKindNames {
+				ch <- prometheus.MustNewConstMetric(clusterWorkloadAllocated, prometheus.GaugeValue, u.workloadAllocated[kind], name, resStr)
+			}
+		}
+
+		if c.opts.EnableQOSMetrics {
+			for class, name := range qosClassNames {
+				ch <- prometheus.MustNewConstMetric(clusterQOSAllocated, prometheus.GaugeValue, u.qosAllocated[class], name, resStr)
+			}
+		}
+
+		if c.opts.EnablePriorityClassMetrics {
+			for class, allocated := range sumPriorityClassAllocated(aggregated, res) {
+				ch <- prometheus.MustNewConstMetric(clusterPriorityClassAllocated, prometheus.GaugeValue, allocated, class, resStr)
+			}
+		}
+
+		for i, threshold := range c.opts.PriorityThresholds {
+			ch <- prometheus.MustNewConstMetric(clusterPriorityEffectiveFree, prometheus.GaugeValue, sliceValue(u.priorityFree, i), resStr, strconv.Itoa(int(threshold)))
+		}
+
+		if c.opts.EnableLimitsMetrics {
+			ch <- prometheus.MustNewConstMetric(clusterLimitsAllocated, prometheus.GaugeValue, u.limitsAllocated, resStr)
+			ch <- prometheus.MustNewConstMetric(clusterOvercommitRatio, prometheus.GaugeValue, safeRatio(u.limitsAllocated, u.allocatable), resStr)
+			ch <- prometheus.MustNewConstMetric(clusterPodsWithoutLimits, prometheus.GaugeValue, float64(u.podsWithoutLimits), resStr)
+		}
+	}
+
+	clusterResizing := make(map[string]int)
+	for _, usage := range aggregated {
+		for state, count := range usage.resizingPods {
+			clusterResizing[state] += count
+		}
+	}
+	for _, state := range resizeStates {
+		ch <- prometheus.MustNewConstMetric(clusterResizingPods, prometheus.GaugeValue, float64(clusterResizing[state]), state)
+	}
+
+	ch <- prometheus.MustNewConstMetric(clusterDominantUtilization, prometheus.GaugeValue, averageDominantUtilization(aggregated))
+
+	if c.opts.EnableQOSMetrics {
+		c.emitQOSPods(ch, clusterQOSPods, aggregated)
+	}
+
+	if c.opts.ConsolidationThreshold > 0 {
+		for _, key := range c.consolidationKeys(resources) {
+			ch <- prometheus.MustNewConstMetric(clusterUnderutilizedNodes, prometheus.GaugeValue, float64(countUnderutilized(aggregated, key)), key)
+		}
+	}
+
+	// Emit cluster node count
+	ch <- prometheus.MustNewConstMetric(clusterNodeCount, prometheus.GaugeValue, float64(len(nodes)))
+	c.emitNodeStateMetrics(ch, usages, resources)
+
+	c.emitPendingMetrics(ch, pending, resources)
+
+	// Emit label-group metrics if configured.
+	if len(c.labelGroups) > 0 {
+		c.collectLabelGroupMetrics(ch, usages, resources)
+	}
+}
+
+// resolveResources returns the resources to report in this scrape. Exact
+// names are kept as configured; patterns (path.Match syntax, e.g.
+// "hugepages-*" or "nvidia.com/*") and "auto" expand in place, sorted, to the
+// matching resources found in any node's allocatable.
+func (c *BinpackingCollector) resolveResources(nodes []*corev1.Node) []corev1.ResourceName {
+	if !slices.ContainsFunc(c.resources, isResourcePattern) {
+		return c.resources
+	}
+
+	available := make(map[corev1.ResourceName]struct{})
+	for _, node := range nodes {
+		for res := range node.Status.Allocatable {
+			available[res] = struct{}{}
+		}
+	}
+
+	seen := make(map[corev1.ResourceName]bool)
+	resolved := make([]corev1.ResourceName, 0, len(c.resources))
+	for _, pattern := range c.resources {
+		if !isResourcePattern(pattern) {
+			if !seen[pattern] {
+				seen[pattern] = true
+				resolved = append(resolved, pattern)
+			}
+			continue
+		}
+
+		var matches []corev1.ResourceName
+		for res := range available {
+			// An alias would report its own values under the resource's
+			// name, so patterns only expand to resources without one.
+			if _, isAlias := c.aliases[res]; isAlias {
+				continue
+			}
+			if !seen[res] && matchResource(pattern, res) {
+				matches = append(matches, res)
+			}
+		}
+		slices.Sort(matches)
+		for _, res := range matches {
+			seen[res] = true
+		}
+		resolved = append(resolved, matches...)
+	}
+	return resolved
+}
+
+// warnShadowingAliases logs a warning, once per alias, for aliases named like
+// a resource the nodes offer. The alias series replace that resource's, so its
+// own values are not reported.
+func (c *BinpackingCollector) warnShadowingAliases(nodes []*corev1.Node) {
+	for name := range c.aliases {
+		for _, node := range nodes {
+			if _, ok := node.Status.Allocatable[name]; !ok {
+				continue
+			}
+			if _, warned := c.shadowingAliases.LoadOrStore(name, struct{}{}); !warned {
+				c.logger.Warn("resource alias has the name of an allocatable resource, which is not reported; rename the alias",
+					"alias", name, "node", node.Name)
+			}
+			break
+		}
+	}
+}
+
+// isResourcePattern returns true if the tracked resource is "auto" or a glob
+// pattern rather than a resource name.
+func isResourcePattern(res corev1.ResourceName) bool {
+	return res == resourceAuto || strings.ContainsAny(string(res), "*?[")
+}
+
+// matchResource reports whether the resource matches the pattern. Patterns
+// are validated at startup, see validateResources.
+func matchResource(pattern, res corev1.ResourceName) bool {
+	if pattern == resourceAuto {
+		return true
+	}
+	matched, _ := path.Match(string(pattern), string(res))
+	return matched
+}
+
+// computeNodeUsage sums the effective requests of the pods scheduled on a node
+// for every tracked resource.
+func (c *BinpackingCollector) computeNodeUsage(node *corev1.Node, nodePods []*corev1.Pod, resources []corev1.ResourceName) *nodeUsage {
+	c.logger.Debug("processing node", "node", node.Name, "pod_count", len(nodePods))
+
+	usage := &nodeUsage{
+		node:         node,
+		resources:    make(map[corev1.ResourceName]resourceUsage, len(resources)),
+		resizingPods: make(map[string]int),
+		state:        nodeState(node),
+	}
+
+	for _, pod := range nodePods {
+		if state := podResizeState(pod); state != "" {
+			usage.resizingPods[state]++
+		}
+		if c.opts.EnableQOSMetrics && (pod.DeletionTimestamp == nil || !c.opts.ExcludeTerminatingPods) {
+			usage.qosPods[podQOSClass(pod)]++
+		}
+	}
+
+	var podRequests []map[corev1.ResourceName]float64
+	if c.opts.SimulateConsolidation {
+		podRequests = make([]map[corev1.ResourceName]float64, len(nodePods))
+		for i := range podRequests {
+			podRequests[i] = make(map[corev1.ResourceName]float64, len(resources))
+		}
+	}
+
+	if c.opts.EnableNamespaceAllocation {
+		usage.namespaceAllocated = make(map[corev1.ResourceName]map[string]float64, len(resources))
+	}
+	if c.opts.EnablePriorityClassMetrics {
+		usage.priorityClassAllocated = make(map[corev1.ResourceName]map[string]float64, len(resources))
+	}
+
+	// Match each pod against the overhead classes once, not per resource.
+	var podClasses [][]int
+	if len(c.opts.OverheadClasses) > 0 {
+		podClasses = make([][]int, len(nodePods))
+		for i, pod := range nodePods {
+			for j, class := range c.opts.OverheadClasses {
+				if class.matches(pod) {
+					podClasses[i] = append(podClasses[i], j)
+				}
+			}
+		}
+	}
+
+	for _, res := range resources {
+		resStr := string(res)
+
+		var namespaces map[string]float64
+		if usage.namespaceAllocated != nil {
+			namespaces = make(map[string]float64)
+			usage.namespaceAllocated[res] = namespaces
+		}
+		var priorityClasses map[string]float64
+		if usage.priorityClassAllocated != nil {
+			priorityClasses = make(map[string]float64)
+			usage.priorityClassAllocated[res] = priorityClasses
+		}
+		// Requests of pods of at least each priority threshold.
+		var prioritized []float64
+		if len(c.opts.PriorityThresholds) > 0 {
+			prioritized = make([]float64, len(c.opts.PriorityThresholds))
+		}
+
+		// Sum the effective pod requests for this resource on this node
+		// (see calculatePodRequest for the init container and sidecar rules).
+		var u resourceUsage
+		if podClasses != nil {
+			u.classOverhead = make([]float64, len(c.opts.OverheadClasses))
+		}
+		for i, pod := range nodePods {
+			podRequest, details := c.podRequest(pod, res)
+			if pod.DeletionTimestamp != nil {
+				u.terminating += podRequest
+				if c.opts.ExcludeTerminatingPods {
+					continue
+				}
+			}
+			u.allocated += podRequest
+			u.podOverhead += details.overhead
+			if namespaces != nil && podRequest > 0 {
+				namespaces[pod.Namespace] += podRequest
+			}
+			if priorityClasses != nil && podRequest > 0 {
+				priorityClasses[podPriorityClass(pod)] += podRequest
+			}
+			for j, threshold := range c.opts.PriorityThresholds {
+				if podPriority(pod) >= threshold {
+					prioritized[j] += podRequest
+				}
+			}
+			if podRequests != nil {
+				podRequests[i][res] = podRequest
+			}
+
+			if isDaemonSetPod(pod) {
+				u.daemonsetOverhead += podRequest
+			} else if isStaticPod(pod) {
+				u.staticPodOverhead += podRequest
+			}
+			if podClasses != nil {
+				for _, class := range podClasses[i] {
+					u.classOverhead[class] += podRequest
+				}
+			}
+
+			if c.opts.EnableWorkloadKindMetrics {
+				u.workloadAllocated[podWorkloadKind(pod)] += podRequest
+			}
+
+			if c.opts.EnableQOSMetrics {
+				u.qosAllocated[podQOSClass(pod)] += podRequest
+			}
+
+			if c.opts.EnableLimitsMetrics {
+				podLimit, bounded := c.podLimit(pod, res)
+				u.limitsAllocated += podLimit
+				if !bounded {
+					u.podsWithoutLimits++
+				}
+			}
+
+			if c.logger.Enabled(context.TODO(), slog.LevelDebug) && podRequest > 0 {
+				switch {
+				case details.podLevel:
+					c.logger.Debug("pod resource request (pod-level resources)",
+						"pod", pod.Namespace+"/"+pod.Name,
+						"resource", resStr,
+						"effective", details.effective,
+						"container_sum", details.regularSum+details.sidecarSum,
+						"init_max", details.initMax,
+						"overhead", details.overhead)
+				case details.usedInit:
+					c.logger.Debug("pod resource request (init container dominates)",
+						"pod", pod.Namespace+"/"+pod.Name,
+						"resource", resStr,
+						"effective", details.effective,
+						"init_max", details.initMax,
+						"init_container", details.initMaxContainer,
+						"regular_sum", details.regularSum,
+						"sidecar_sum", details.sidecarSum,
+						"overhead", details.overhead)
+				default:
+					c.logger.Debug("pod resource request",
+						"pod", pod.Namespace+"/"+pod.Name,
+						"resource", resStr,
+						"effective", details.effective,
+						"containers", details.containerCount,
+						"init_containers", details.initContainerCount,
+						"sidecars", details.sidecarCount,
+						"sidecar_sum", details.sidecarSum,
+						"overhead", details.overhead)
+				}
+			}
+		}
+
+		u.allocatable = c.resourceValue(node.Status.Allocatable, res)
+		// A node that does not report capacity has nothing reserved.
+		u.capacity = max(c.resourceValue(node.Status.Capacity, res), u.allocatable)
+		if prioritized != nil {
+			u.priorityFree = make([]float64, len(prioritized))
+			for j, requested := range prioritized {
+				u.priorityFree[j] = max(u.allocatable-requested, 0)
+			}
+		}
+
+		usage.resources[res] = u
+	}
+
+	for i, pod := range nodePods {
+		if podRequests != nil && !isDaemonSetPod(pod) && !isStaticPod(pod) {
+			usage.workloadRequests = append(usage.workloadRequests, podRequests[i])
+		}
+	}
+
+	c.computeStranded(usage, resources)
+	usage.dominantResource, usage.dominantRatio = dominantUtilization(usage, resources)
+	if c.opts.ConsolidationThreshold > 0 {
+		c.computeUnderutilized(usage, resources)
+	}
+
+	return usage
+}
+
+// dominantUtilization returns the resource with the highest utilization ratio
+// on the node, i.e. the one that limits further packing, and that ratio.
+// Resources without allocatable are ignored; ties keep the first resource.
+func dominantUtilization(usage *nodeUsage, resources []corev1.ResourceName) (corev1.ResourceName, float64) {
+	var dominant corev1.ResourceName
+	var ratio float64
+	for _, res := range resources {
+		u := usage.resources[res]
+		if u.allocatable <= 0 {
+			continue
+		}
+		if r := u.allocated / u.allocatable; dominant == "" || r > ratio {
+			dominant, ratio = res, r
+		}
+	}
+	return dominant, ratio
+}
+
+// computeUnderutilized marks the node as a consolidation candidate when its
+// dominant utilization, or in per-resource mode each resource's utilization,
+// is below the consolidation threshold.
+func (c *BinpackingCollector) computeUnderutilized(usage *nodeUsage, resources []corev1.ResourceName) {
+	usage.underutilized = make(map[string]bool)
+	if c.opts.ConsolidationMode == ConsolidationPerResource {
+		for _, res := range resources {
+			if u := usage.resources[res]; u.allocatable > 0 {
+				usage.underutilized[string(res)] = u.allocated/u.allocatable < c.opts.ConsolidationThreshold
+			}
+		}
+		return
+	}
+	if usage.dominantResource != "" {
+		usage.underutilized[consolidationDominant] = usage.dominantRatio < c.opts.ConsolidationThreshold
+	}
+}
+
+// consolidationKeys returns the resource label values of the consolidation
+// metrics for the configured mode.
+func (c *BinpackingCollector) consolidationKeys(resources []corev1.ResourceName) []string {
+	if c.opts.ConsolidationMode != ConsolidationPerResource {
+		return []string{consolidationDominant}
+	}
+	keys := make([]string, len(resources))
+	for i, res := range resources {
+		keys[i] = string(res)
+	}
+	return keys
+}
+
+// countUnderutilized returns the number of consolidation candidates for key.
+func countUnderutilized(usages []*nodeUsage, key string) int {
+	var count int
+	for _, usage := range usages {
+		if usage.underutilized[key] {
+			count++
+		}
+	}
+	return count
+}
+
+// averageDominantUtilization returns the mean dominant utilization ratio over
+// the nodes that have one.
+func averageDominantUtilization(usages []*nodeUsage) float64 {
+	var sum float64
+	var count int
+	for _, usage := range usages {
+		if usage.dominantResource != "" {
+			sum += usage.dominantRatio
+			count++
+		}
+	}
+	return safeRatio(sum, float64(count))
+}
+
+// computeStranded sets the stranded amount of every resource on the node: its
+// free amount if another tracked resource is saturated (utilization at or
+// above the stranded threshold), since no further pod can be placed to use it.
+func (c *BinpackingCollector) computeStranded(usage *nodeUsage, resources []corev1.ResourceName) {
+	for _, res := range resources {
+		u := usage.resources[res]
+		free := u.allocatable - u.allocated
+		if free <= 0 {
+			continue
+		}
+		for _, other := range resources {
+			if other == res || c.isAliasOf(res, other) || c.isAliasOf(other, res) {
+				continue
+			}
+			o := usage.resources[other]
+			if o.allocatable > 0 && o.allocated/o.allocatable >= c.opts.StrandedThreshold {
+				u.stranded = free
+				usage.resources[res] = u
+				break
+			}
+		}
+	}
+}
+
+// isAliasOf returns true if res is an alias with source as one of its source
+// resources. An alias and its sources measure the same capacity, so one
+// cannot strand the other.
+func (c *BinpackingCollector) isAliasOf(res, source corev1.ResourceName) bool {
+	alias, ok := c.aliases[res]
+	if !ok {
+		return false
 	}
+	return slices.ContainsFunc(alias.Sources, func(src AliasSource) bool { return src.Resource == source })
+}
 
-	pods, err := c.podLister.List(labels.Everything())
-	if err != nil {
-		c.logger.Error("failed to list pods", "error", err)
-		return
+// podRequest returns the effective request of the pod for the resource,
+// summing the weighted source resources if it is an alias.
+func (c *BinpackingCollector) podRequest(pod *corev1.Pod, res corev1.ResourceName) (float64, podRequestDetails) {
+	alias, ok := c.aliases[res]
+	if !ok {
+		return calculatePodRequest(pod, res)
 	}
 
-	c.logger.Debug("scraping metrics", "node_count", len(nodes), "pod_count", len(pods))
+	var details podRequestDetails
+	for _, src := range alias.Sources {
+		val, d := calculatePodRequest(pod, src.Resource)
+		details.effective += src.Weight * val
+		details.overhead += src.Weight * d.overhead
+		details.containerCount += d.containerCount
+		details.initContainerCount += d.initContainerCount
+		details.sidecarCount += d.sidecarCount
+	}
+	return details.effective, details
+}
 
-	// Build podsByNode map, filtering out unscheduled and terminated pods.
-	podsByNode := make(map[string][]*corev1.Pod)
-	var unscheduledCount, terminatedCount int
-	for _, pod := range pods {
-		if pod.Spec.NodeName == "" {
-			unscheduledCount++
-			c.logger.Debug("skipping unscheduled pod", "pod", pod.Namespace+"/"+pod.Name)
-			continue
-		}
-		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
-			terminatedCount++
-			c.logger.Debug("skipping terminated pod", "pod", pod.Namespace+"/"+pod.Name, "phase", pod.Status.Phase)
-			continue
-		}
-		podsByNode[pod.Spec.NodeName] = append(podsByNode[pod.Spec.NodeName], pod)
+// podLimit returns the effective limit of the pod for the resource, summing
+// the weighted source resources if it is an alias. The alias is bounded only
+// if every source is.
+func (c *BinpackingCollector) podLimit(pod *corev1.Pod, res corev1.ResourceName) (float64, bool) {
+	alias, ok := c.aliases[res]
+	if !ok {
+		return calculatePodLimit(pod, res)
 	}
 
-	if unscheduledCount > 0 || terminatedCount > 0 {
-		c.logger.Debug("filtered pods", "unscheduled", unscheduledCount, "terminated", terminatedCount)
+	var limit float64
+	bounded := true
+	for _, src := range alias.Sources {
+		val, b := calculatePodLimit(pod, src.Resource)
+		limit += src.Weight * val
+		bounded = bounded && b
 	}
+	return limit, bounded
+}
 
-	// Track cluster-wide totals per resource.
-	clusterAllocatedTotals := make(map[corev1.ResourceName]float64)
-	clusterAllocatableTotals := make(map[corev1.ResourceName]float64)
-	clusterDaemonsetTotals := make(map[corev1.ResourceName]float64)
+// resourceValue returns the value of the resource in a node resource list
+// (allocatable or capacity), summing the weighted source resources if it is
+// an alias.
+func (c *BinpackingCollector) resourceValue(list corev1.ResourceList, res corev1.ResourceName) float64 {
+	alias, ok := c.aliases[res]
+	if !ok {
+		return quantityValue(list, res)
+	}
 
-	for _, node := range nodes {
-		nodePods := podsByNode[node.Name]
+	var total float64
+	for _, src := range alias.Sources {
+		total += src.Weight * quantityValue(list, src.Resource)
+	}
+	return total
+}
 
-		c.logger.Debug("processing node", "node", node.Name, "pod_count", len(nodePods))
+// quantityValue returns the value of the resource in the list, or 0.
+func quantityValue(list corev1.ResourceList, res corev1.ResourceName) float64 {
+	if qty, ok := list[res]; ok {
+		return qty.AsApproximateFloat64()
+	}
+	return 0
+}
 
-		for _, res := range c.resources {
-			resStr := string(res)
+// emitNodeMetrics emits the per-node metrics for a single node.
+func (c *BinpackingCollector) emitNodeMetrics(ch chan<- prometheus.Metric, usage *nodeUsage, resources []corev1.ResourceName) {
+	nodeName := usage.node.Name
+	for _, res := range resources {
+		resStr := string(res)
+		u := usage.resources[res]
+		ratio := safeRatio(u.allocated, u.allocatable)
+		dsRatio := safeRatio(u.daemonsetOverhead, u.allocatable)
 
-			// Sum pod requests for this resource on this node.
-			// For each pod, take the max of:
-			// 1. Sum of all regular container requests
-			// 2. Max init container request (they run sequentially)
-			var allocated float64
-			var daemonsetOverhead float64
-			for _, pod := range nodePods {
-				podRequest, details := calculatePodRequest(pod, res)
-				allocated += podRequest
+		c.logger.Debug("node metrics",
+			"node", nodeName,
+			"resource", resStr,
+			"allocated", u.allocated,
+			"allocatable", u.allocatable,
+			"utilization", ratio,
+			"daemonset_overhead", u.daemonsetOverhead,
+			"pod_overhead", u.podOverhead)
 
-				if isDaemonSetPod(pod) {
-					daemonsetOverhead += podRequest
-				}
+		ch <- prometheus.MustNewConstMetric(nodeAllocated, prometheus.GaugeValue, u.allocated, nodeName, resStr)
+		ch <- prometheus.MustNewConstMetric(nodeAllocatable, prometheus.GaugeValue, u.allocatable, nodeName, resStr)
+		ch <- prometheus.MustNewConstMetric(nodeUtilization, prometheus.GaugeValue, ratio, nodeName, resStr)
+		ch <- prometheus.MustNewConstMetric(nodeDaemonsetOverhead, prometheus.GaugeValue, u.daemonsetOverhead, nodeName, resStr)
+		ch <- prometheus.MustNewConstMetric(nodeDaemonsetOverheadRatio, prometheus.GaugeValue, dsRatio, nodeName, resStr)
+		ch <- prometheus.MustNewConstMetric(nodeStaticPodOverhead, prometheus.GaugeValue, u.staticPodOverhead, nodeName, resStr)
+		ch <- prometheus.MustNewConstMetric(nodeStaticPodOverheadRatio, prometheus.GaugeValue, safeRatio(u.staticPodOverhead, u.allocatable), nodeName, resStr)
+		for i, class := range c.opts.OverheadClasses {
+			ch <- prometheus.MustNewConstMetric(nodeClassOverhead, prometheus.GaugeValue, sliceValue(u.classOverhead, i), nodeName, class.Name, resStr)
+			ch <- prometheus.MustNewConstMetric(nodeClassOverheadRatio, prometheus.GaugeValue, safeRatio(sliceValue(u.classOverhead, i), u.allocatable), nodeName, class.Name, resStr)
+		}
+		ch <- prometheus.MustNewConstMetric(nodePodOverhead, prometheus.GaugeValue, u.podOverhead, nodeName, resStr)
+		ch <- prometheus.MustNewConstMetric(nodeTerminatingAllocated, prometheus.GaugeValue, u.terminating, nodeName, resStr)
+		ch <- prometheus.MustNewConstMetric(nodeCapacity, prometheus.GaugeValue, u.capacity, nodeName, resStr)
+		ch <- prometheus.MustNewConstMetric(nodeReservedRatio, prometheus.GaugeValue, u.reservedRatio(), nodeName, resStr)
+		ch <- prometheus.MustNewConstMetric(nodeStranded, prometheus.GaugeValue, u.stranded, nodeName, resStr)
+		ch <- prometheus.MustNewConstMetric(nodeStrandedRatio, prometheus.GaugeValue, safeRatio(u.stranded, u.allocatable), nodeName, resStr)
 
-				if c.logger.Enabled(context.TODO(), slog.LevelDebug) && podRequest > 0 {
-					if details.usedInit {
-						c.logger.Debug("pod resource request (init container dominates)",
-							"pod", pod.Namespace+"/"+pod.Name,
-							"resource", resStr,
-							"effective", details.effective,
-							"init_max", details.initMax,
-							"init_container", details.initMaxContainer,
-							"regular_sum", details.regularSum)
-					} else {
-						c.logger.Debug("pod resource request",
-							"pod", pod.Namespace+"/"+pod.Name,
-							"resource", resStr,
-							"effective", details.effective,
-							"containers", details.containerCount,
-							"init_containers", details.initContainerCount)
-					}
-				}
+		if c.opts.EnableWorkloadKindMetrics {
+			for kind, name := range workloadKindNames {
+				ch <- prometheus.MustNewConstMetric(nodeWorkloadAllocated, prometheus.GaugeValue, u.workloadAllocated[kind], nodeName, name, resStr)
 			}
+		}
 
-			// Get node allocatable for this resource.
-			var allocatable float64
-			if qty, ok := node.Status.Allocatable[res]; ok {
-				allocatable = qty.AsApproximateFloat64()
+		if c.opts.EnableQOSMetrics {
+			for class, name := range qosClassNames {
+				ch <- prometheus.MustNewConstMetric(nodeQOSAllocated, prometheus.GaugeValue, u.qosAllocated[class], nodeName, name, resStr)
 			}
+		}
 
-			// Compute ratios.
-			var ratio float64
-			if allocatable > 0 {
-				ratio = allocated / allocatable
-			}
-			var dsRatio float64
-			if allocatable > 0 {
-				dsRatio = daemonsetOverhead / allocatable
+		if c.opts.EnablePriorityClassMetrics {
+			for class, allocated := range usage.priorityClassAllocated[res] {
+				ch <- prometheus.MustNewConstMetric(nodePriorityClassAllocated, prometheus.GaugeValue, allocated, nodeName, class, resStr)
 			}
+		}
 
-			// Emit per-node metrics if enabled
-			if c.enableNodeMetrics {
-				c.logger.Debug("node metrics",
-					"node", node.Name,
-					"resource", resStr,
-					"allocated", allocated,
-					"allocatable", allocatable,
-					"utilization", ratio,
-					"daemonset_overhead", daemonsetOverhead)
+		if c.opts.EnableLimitsMetrics {
+			ch <- prometheus.MustNewConstMetric(nodeLimitsAllocated, prometheus.GaugeValue, u.limitsAllocated, nodeName, resStr)
+			ch <- prometheus.MustNewConstMetric(nodeOvercommitRatio, prometheus.GaugeValue, safeRatio(u.limitsAllocated, u.allocatable), nodeName, resStr)
+			ch <- prometheus.MustNewConstMetric(nodePodsWithoutLimits, prometheus.GaugeValue, float64(u.podsWithoutLimits), nodeName, resStr)
+		}
+	}
 
-				ch <- prometheus.MustNewConstMetric(nodeAllocated, prometheus.GaugeValue, allocated, node.Name, resStr)
-				ch <- prometheus.MustNewConstMetric(nodeAllocatable, prometheus.GaugeValue, allocatable, node.Name, resStr)
-				ch <- prometheus.MustNewConstMetric(nodeUtilization, prometheus.GaugeValue, ratio, node.Name, resStr)
-				ch <- prometheus.MustNewConstMetric(nodeDaemonsetOverhead, prometheus.GaugeValue, daemonsetOverhead, node.Name, resStr)
-				ch <- prometheus.MustNewConstMetric(nodeDaemonsetOverheadRatio, prometheus.GaugeValue, dsRatio, node.Name, resStr)
-			}
+	// Resizes are rare, so only states with pods get a node series; the
+	// cluster series cover every state.
+	for _, state := range resizeStates {
+		if n := usage.resizingPods[state]; n > 0 {
+			ch <- prometheus.MustNewConstMetric(nodeResizingPods, prometheus.GaugeValue, float64(n), nodeName, state)
+		}
+	}
+
+	ch <- prometheus.MustNewConstMetric(nodeStateInfo, prometheus.GaugeValue, 1, nodeName, usage.state)
 
-			clusterAllocatedTotals[res] += allocated
-			clusterAllocatableTotals[res] += allocatable
-			clusterDaemonsetTotals[res] += daemonsetOverhead
+	if c.opts.EnableQOSMetrics {
+		for class, name := range qosClassNames {
+			ch <- prometheus.MustNewConstMetric(nodeQOSPods, prometheus.GaugeValue, float64(usage.qosPods[class]), nodeName, name)
 		}
 	}
 
-	// Emit cluster-aggregate metrics.
-	for _, res := range c.resources {
-		resStr := string(res)
-		allocated := clusterAllocatedTotals[res]
-		allocatable := clusterAllocatableTotals[res]
-		dsOverhead := clusterDaemonsetTotals[res]
+	if usage.dominantResource != "" {
+		ch <- prometheus.MustNewConstMetric(nodeDominantUtilization, prometheus.GaugeValue, usage.dominantRatio, nodeName, string(usage.dominantResource))
+	}
+
+	if c.opts.ConsolidationThreshold > 0 {
+		for _, key := range c.consolidationKeys(resources) {
+			if candidate, ok := usage.underutilized[key]; ok {
+				ch <- prometheus.MustNewConstMetric(nodeConsolidationCandidate, prometheus.GaugeValue, boolToFloat64(candidate), nodeName, key)
+			}
+		}
+	}
+}
 
-		var ratio float64
-		if allocatable > 0 {
-			ratio = allocated / allocatable
+// emitPendingMetrics emits the demand of pods that are waiting to be scheduled,
+// using the same request accounting as scheduled pods.
+func (c *BinpackingCollector) emitPendingMetrics(ch chan<- prometheus.Metric, pending []*corev1.Pod, resources []corev1.ResourceName) {
+	var oldestAge float64
+	namespaceCounts := make(map[string]int)
+	for _, pod := range pending {
+		if !pod.CreationTimestamp.IsZero() {
+			oldestAge = max(oldestAge, time.Since(pod.CreationTimestamp.Time).Seconds())
 		}
-		var dsRatio float64
-		if allocatable > 0 {
-			dsRatio = dsOverhead / allocatable
+		namespaceCounts[pod.Namespace]++
+	}
+
+	ch <- prometheus.MustNewConstMetric(pendingPods, prometheus.GaugeValue, float64(len(pending)))
+	ch <- prometheus.MustNewConstMetric(pendingOldestAge, prometheus.GaugeValue, oldestAge)
+
+	for _, res := range resources {
+		resStr := string(res)
+		var total float64
+		namespaceTotals := make(map[string]float64)
+		for _, pod := range pending {
+			podRequest, _ := c.podRequest(pod, res)
+			total += podRequest
+			namespaceTotals[pod.Namespace] += podRequest
 		}
 
-		c.logger.Debug("cluster metrics",
+		c.logger.Debug("pending metrics",
 			"resource", resStr,
-			"allocated", allocated,
-			"allocatable", allocatable,
-			"utilization", ratio,
-			"daemonset_overhead", dsOverhead)
+			"requested", total,
+			"pod_count", len(pending))
 
-		ch <- prometheus.MustNewConstMetric(clusterAllocated, prometheus.GaugeValue, allocated, resStr)
-		ch <- prometheus.MustNewConstMetric(clusterAllocatable, prometheus.GaugeValue, allocatable, resStr)
-		ch <- prometheus.MustNewConstMetric(clusterUtilization, prometheus.GaugeValue, ratio, resStr)
-		ch <- prometheus.MustNewConstMetric(clusterDaemonsetOverhead, prometheus.GaugeValue, dsOverhead, resStr)
-		ch <- prometheus.MustNewConstMetric(clusterDaemonsetOverheadRatio, prometheus.GaugeValue, dsRatio, resStr)
-	}
+		ch <- prometheus.MustNewConstMetric(pendingRequested, prometheus.GaugeValue, total, resStr)
 
-	// Emit cluster node count
-	ch <- prometheus.MustNewConstMetric(clusterNodeCount, prometheus.GaugeValue, float64(len(nodes)))
+		if c.opts.EnablePendingByNamespace {
+			for namespace, nsTotal := range namespaceTotals {
+				ch <- prometheus.MustNewConstMetric(namespacePendingRequested, prometheus.GaugeValue, nsTotal, namespace, resStr)
+			}
+		}
+	}
 
-	// Emit label-group metrics if configured.
-	if len(c.labelGroups) > 0 {
-		c.collectLabelGroupMetrics(ch, nodes, podsByNode)
+	if c.opts.EnablePendingByNamespace {
+		for namespace, count := range namespaceCounts {
+			ch <- prometheus.MustNewConstMetric(namespacePendingPods, prometheus.GaugeValue, float64(count), namespace)
+		}
 	}
 }
 
 // collectLabelGroupMetrics calculates and emits binpacking metrics grouped by node label combinations.
 // Each group is a slice of label keys. Nodes are grouped by the composite value of all keys in the group.
-func (c *BinpackingCollector) collectLabelGroupMetrics(ch chan<- prometheus.Metric, nodes []*corev1.Node, podsByNode map[string][]*corev1.Pod) {
+func (c *BinpackingCollector) collectLabelGroupMetrics(ch chan<- prometheus.Metric, usages []*nodeUsage, resources []corev1.ResourceName) {
 	for _, group := range c.labelGroups {
 		labelGroupKey := strings.Join(group, ",")
 
 		// Group nodes by composite label value.
-		nodesByCompositeValue := make(map[string][]*corev1.Node)
-		for _, node := range nodes {
+		usagesByCompositeValue := make(map[string][]*nodeUsage)
+		for _, usage := range usages {
 			values := make([]string, len(group))
 			for i, key := range group {
-				if v, ok := node.Labels[key]; ok {
+				if v, ok := usage.node.Labels[key]; ok {
 					values[i] = v
 				} else {
 					values[i] = "<none>"
 				}
 			}
 			compositeValue := strings.Join(values, ",")
-			nodesByCompositeValue[compositeValue] = append(nodesByCompositeValue[compositeValue], node)
+			usagesByCompositeValue[compositeValue] = append(usagesByCompositeValue[compositeValue], usage)
 		}
 
 		c.logger.Debug("grouping nodes by label combination",
 			"label_group", labelGroupKey,
-			"group_count", len(nodesByCompositeValue))
-
-		// For each composite value, calculate aggregate binpacking metrics.
-		for compositeValue, groupNodes := range nodesByCompositeValue {
-			allocatedTotals := make(map[corev1.ResourceName]float64)
-			allocatableTotals := make(map[corev1.ResourceName]float64)
-			daemonsetTotals := make(map[corev1.ResourceName]float64)
-
-			for _, node := range groupNodes {
-				nodePods := podsByNode[node.Name]
-
-				for _, res := range c.resources {
-					var allocated float64
-					var dsOverhead float64
-					for _, pod := range nodePods {
-						podRequest, _ := calculatePodRequest(pod, res)
-						allocated += podRequest
-						if isDaemonSetPod(pod) {
-							dsOverhead += podRequest
-						}
-					}
+			"group_count", len(usagesByCompositeValue))
 
-					var allocatable float64
-					if qty, ok := node.Status.Allocatable[res]; ok {
-						allocatable = qty.AsApproximateFloat64()
-					}
-
-					allocatedTotals[res] += allocated
-					allocatableTotals[res] += allocatable
-					daemonsetTotals[res] += dsOverhead
-				}
-			}
+		// For each composite value, emit aggregate binpacking metrics.
+		for compositeValue, groupNodes := range usagesByCompositeValue {
+			groupUsages := c.aggregatedUsages(groupNodes)
+			totals := sumNodeUsage(groupUsages)
 
-			// Emit metrics for this combination group.
-			for _, res := range c.resources {
+			for _, res := range resources {
 				resStr := string(res)
-				allocated := allocatedTotals[res]
-				allocatable := allocatableTotals[res]
-				dsOverhead := daemonsetTotals[res]
-
-				var ratio float64
-				if allocatable > 0 {
-					ratio = allocated / allocatable
-				}
-				var dsRatio float64
-				if allocatable > 0 {
-					dsRatio = dsOverhead / allocatable
-				}
+				u := totals[res]
+				ratio := safeRatio(u.allocated, u.allocatable)
+				dsRatio := safeRatio(u.daemonsetOverhead, u.allocatable)
 
 				c.logger.Debug("group metrics",
 					"label_group", labelGroupKey,
 					"label_group_value", compositeValue,
 					"resource", resStr,
-					"allocated", allocated,
-					"allocatable", allocatable,
+					"allocated", u.allocated,
+					"allocatable", u.allocatable,
 					"utilization", ratio,
-					"daemonset_overhead", dsOverhead,
-					"node_count", len(groupNodes))
+					"daemonset_overhead", u.daemonsetOverhead,
+					"pod_overhead", u.podOverhead,
+					"node_count", len(groupUsages))
 
-				ch <- prometheus.MustNewConstMetric(groupAllocated, prometheus.GaugeValue, allocated, labelGroupKey, compositeValue, resStr)
-				ch <- prometheus.MustNewConstMetric(groupAllocatable, prometheus.GaugeValue, allocatable, labelGroupKey, compositeValue, resStr)
+				ch <- prometheus.MustNewConstMetric(groupAllocated, prometheus.GaugeValue, u.allocated, labelGroupKey, compositeValue, resStr)
+				ch <- prometheus.MustNewConstMetric(groupAllocatable, prometheus.GaugeValue, u.allocatable, labelGroupKey, compositeValue, resStr)
 				ch <- prometheus.MustNewConstMetric(groupUtilization, prometheus.GaugeValue, ratio, labelGroupKey, compositeValue, resStr)
-				ch <- prometheus.MustNewConstMetric(groupDaemonsetOverhead, prometheus.GaugeValue, dsOverhead, labelGroupKey, compositeValue, resStr)
+				ch <- prometheus.MustNewConstMetric(groupDaemonsetOverhead, prometheus.GaugeValue, u.daemonsetOverhead, labelGroupKey, compositeValue, resStr)
 				ch <- prometheus.MustNewConstMetric(groupDaemonsetOverheadRatio, prometheus.GaugeValue, dsRatio, labelGroupKey, compositeValue, resStr)
+				ch <- prometheus.MustNewConstMetric(groupStaticPodOverhead, prometheus.GaugeValue, u.staticPodOverhead, labelGroupKey, compositeValue, resStr)
+				ch <- prometheus.MustNewConstMetric(groupStaticPodOverheadRatio, prometheus.GaugeValue, safeRatio(u.staticPodOverhead, u.allocatable), labelGroupKey, compositeValue, resStr)
+				for i, class := range c.opts.OverheadClasses {
+					ch <- prometheus.MustNewConstMetric(groupClassOverhead, prometheus.GaugeValue, sliceValue(u.classOverhead, i), labelGroupKey, compositeValue, class.Name, resStr)
+					ch <- prometheus.MustNewConstMetric(groupClassOverheadRatio, prometheus.GaugeValue, safeRatio(sliceValue(u.classOverhead, i), u.allocatable), labelGroupKey, compositeValue, class.Name, resStr)
+				}
+				ch <- prometheus.MustNewConstMetric(groupPodOverhead, prometheus.GaugeValue, u.podOverhead, labelGroupKey, compositeValue, resStr)
+				ch <- prometheus.MustNewConstMetric(groupTerminatingAllocated, prometheus.GaugeValue, u.terminating, labelGroupKey, compositeValue, resStr)
+				ch <- prometheus.MustNewConstMetric(groupCapacity, prometheus.GaugeValue, u.capacity, labelGroupKey, compositeValue, resStr)
+				ch <- prometheus.MustNewConstMetric(groupReservedRatio, prometheus.GaugeValue, u.reservedRatio(), labelGroupKey, compositeValue, resStr)
+				ch <- prometheus.MustNewConstMetric(groupStranded, prometheus.GaugeValue, u.stranded, labelGroupKey, compositeValue, resStr)
+				ch <- prometheus.MustNewConstMetric(groupStrandedRatio, prometheus.GaugeValue, safeRatio(u.stranded, u.allocatable), labelGroupKey, compositeValue, resStr)
+
+				frag := computeFragmentation(groupUsages, res)
+				ch <- prometheus.MustNewConstMetric(groupLargestFree, prometheus.GaugeValue, frag.largestFree, labelGroupKey, compositeValue, resStr)
+				ch <- prometheus.MustNewConstMetric(groupFragmentationIndex, prometheus.GaugeValue, frag.index(), labelGroupKey, compositeValue, resStr)
+
+				if c.opts.UtilizationHistogram != HistogramNone {
+					ch <- c.utilizationHistogram(groupNodeUtilizationHistogram, groupUsages, res, labelGroupKey, compositeValue, resStr)
+				}
+
+				if c.opts.EnableNamespaceAllocation {
+					for _, ns := range topNamespaces(groupUsages, res, c.opts.NamespaceTopN) {
+						ch <- prometheus.MustNewConstMetric(groupNamespaceAllocated, prometheus.GaugeValue, ns.allocated, labelGroupKey, compositeValue, ns.namespace, resStr)
+					}
+				}
+
+				// Spread of the per-node ratios, skipped if no node in the
+				// group has this resource.
+				if ratios := nodeUtilizationRatios(groupUsages, res); len(ratios) > 0 {
+					slices.Sort(ratios)
+					for _, q := range utilizationQuantiles {
+						ch <- prometheus.MustNewConstMetric(groupNodeUtilizationQuantile, prometheus.GaugeValue, quantile(ratios, q),
+							labelGroupKey, compositeValue, resStr, strconv.FormatFloat(q, 'g', -1, 64))
+					}
+					ch <- prometheus.MustNewConstMetric(groupNodeUtilizationStddev, prometheus.GaugeValue, stddev(ratios), labelGroupKey, compositeValue, resStr)
+				}
+
+				if c.opts.EnableWorkloadKindMetrics {
+					for kind, name := range workloadKindNames {
+						ch <- prometheus.MustNewConstMetric(groupWorkloadAllocated, prometheus.GaugeValue, u.workloadAllocated[kind], labelGroupKey, compositeValue, name, resStr)
+					}
+				}
+
+				if c.opts.EnableQOSMetrics {
+					for class, name := range qosClassNames {
+						ch <- prometheus.MustNewConstMetric(groupQOSAllocated, prometheus.GaugeValue, u.qosAllocated[class], labelGroupKey, compositeValue, name, resStr)
+					}
+				}
+
+				if c.opts.EnablePriorityClassMetrics {
+					for class, allocated := range sumPriorityClassAllocated(groupUsages, res) {
+						ch <- prometheus.MustNewConstMetric(groupPriorityClassAllocated, prometheus.GaugeValue, allocated, labelGroupKey, compositeValue, class, resStr)
+					}
+				}
+
+				for i, threshold := range c.opts.PriorityThresholds {
+					ch <- prometheus.MustNewConstMetric(groupPriorityEffectiveFree, prometheus.GaugeValue, sliceValue(u.priorityFree, i), labelGroupKey, compositeValue, resStr, strconv.Itoa(int(threshold)))
+				}
+
+				if c.opts.EnableLimitsMetrics {
+					ch <- prometheus.MustNewConstMetric(groupLimitsAllocated, prometheus.GaugeValue, u.limitsAllocated, labelGroupKey, compositeValue, resStr)
+					ch <- prometheus.MustNewConstMetric(groupOvercommitRatio, prometheus.GaugeValue, safeRatio(u.limitsAllocated, u.allocatable), labelGroupKey, compositeValue, resStr)
+					ch <- prometheus.MustNewConstMetric(groupPodsWithoutLimits, prometheus.GaugeValue, float64(u.podsWithoutLimits), labelGroupKey, compositeValue, resStr)
+				}
 			}
 
 			ch <- prometheus.MustNewConstMetric(groupNodeCount, prometheus.GaugeValue, float64(len(groupNodes)), labelGroupKey, compositeValue)
+			c.emitNodeStateMetrics(ch, groupNodes, resources, labelGroupKey, compositeValue)
+			ch <- prometheus.MustNewConstMetric(groupDominantUtilization, prometheus.GaugeValue, averageDominantUtilization(groupUsages), labelGroupKey, compositeValue)
+
+			if c.opts.EnableQOSMetrics {
+				c.emitQOSPods(ch, groupQOSPods, groupUsages, labelGroupKey, compositeValue)
+			}
+
+			if c.opts.SimulateConsolidation {
+				lowerBound, firstFit := nodesRequired(groupUsages, resources)
+				ch <- prometheus.MustNewConstMetric(groupNodesRequired, prometheus.GaugeValue, float64(lowerBound), labelGroupKey, compositeValue, "lower_bound")
+				ch <- prometheus.MustNewConstMetric(groupNodesRequired, prometheus.GaugeValue, float64(firstFit), labelGroupKey, compositeValue, "first_fit_decreasing")
+			}
+
+			if c.opts.ConsolidationThreshold > 0 {
+				for _, key := range c.consolidationKeys(resources) {
+					ch <- prometheus.MustNewConstMetric(groupUnderutilizedNodes, prometheus.GaugeValue, float64(countUnderutilized(groupUsages, key)), labelGroupKey, compositeValue, key)
+				}
+			}
+		}
+	}
+}
+
+// emitQOSPods emits the number of pods per QoS class summed over the nodes.
+func (c *BinpackingCollector) emitQOSPods(ch chan<- prometheus.Metric, desc *prometheus.Desc, usages []*nodeUsage, labelValues ...string) {
+	var counts [numQOSClasses]int
+	for _, usage := range usages {
+		for class, n := range usage.qosPods {
+			counts[class] += n
+		}
+	}
+	for class, name := range qosClassNames {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(counts[class]), append(labelValues, name)...)
+	}
+}
+
+// sumPriorityClassAllocated returns the allocated resource per PriorityClass
+// summed over the nodes.
+func sumPriorityClassAllocated(usages []*nodeUsage, res corev1.ResourceName) map[string]float64 {
+	totals := make(map[string]float64)
+	for _, usage := range usages {
+		for class, allocated := range usage.priorityClassAllocated[res] {
+			totals[class] += allocated
+		}
+	}
+	return totals
+}
+
+// namespaceAllocation is the allocated amount of a resource in a namespace.
+type namespaceAllocation struct {
+	namespace string
+	allocated float64
+}
+
+// topNamespaces returns the allocated resource per namespace over the nodes,
+// largest first. If n > 0 only the n largest namespaces are kept and the rest
+// are summed under namespaceOther. Namespaces that request none of the
+// resource are left out.
+func topNamespaces(usages []*nodeUsage, res corev1.ResourceName, n int) []namespaceAllocation {
+	totals := make(map[string]float64)
+	for _, usage := range usages {
+		for namespace, allocated := range usage.namespaceAllocated[res] {
+			totals[namespace] += allocated
+		}
+	}
+
+	allocations := make([]namespaceAllocation, 0, len(totals))
+	for namespace, allocated := range totals {
+		allocations = append(allocations, namespaceAllocation{namespace: namespace, allocated: allocated})
+	}
+	slices.SortFunc(allocations, func(a, b namespaceAllocation) int {
+		if c := cmp.Compare(b.allocated, a.allocated); c != 0 {
+			return c
+		}
+		return strings.Compare(a.namespace, b.namespace)
+	})
+
+	if n <= 0 || len(allocations) <= n {
+		return allocations
+	}
+	other := namespaceAllocation{namespace: namespaceOther}
+	for _, a := range allocations[n:] {
+		other.allocated += a.allocated
+	}
+	return append(allocations[:n], other)
+}
+
+// nodeState returns the state of the node. Deletion takes precedence over
+// not ready, which takes precedence over cordoned. A node without a Ready
+// condition, e.g. one that just registered, counts as ready.
+func nodeState(node *corev1.Node) string {
+	if node.DeletionTimestamp != nil {
+		return nodeStateDeleting
+	}
+
+	state := nodeStateSchedulable
+	if node.Spec.Unschedulable {
+		state = nodeStateCordoned
+	}
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == corev1.NodeReady && cond.Status != corev1.ConditionTrue {
+			state = nodeStateNotReady
+		}
+	}
+	for _, taint := range node.Spec.Taints {
+		if taint.Effect == corev1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		switch nodeStateTaints[taint.Key] {
+		case nodeStateDeleting:
+			return nodeStateDeleting
+		case nodeStateNotReady:
+			state = nodeStateNotReady
+		case nodeStateCordoned:
+			if state == nodeStateSchedulable {
+				state = nodeStateCordoned
+			}
+		}
+	}
+	return state
+}
+
+// aggregatedUsages returns the nodes that count towards cluster and
+// label-group aggregates under the configured NodeStateMode.
+func (c *BinpackingCollector) aggregatedUsages(usages []*nodeUsage) []*nodeUsage {
+	if c.opts.NodeStateMode == NodeStateInclude {
+		return usages
+	}
+	return slices.DeleteFunc(slices.Clone(usages), func(usage *nodeUsage) bool {
+		return usage.state != nodeStateSchedulable
+	})
+}
+
+// emitNodeStateMetrics emits the node counts per state and, in separate mode,
+// allocated and allocatable per state, for the cluster or, given label group
+// values, for a label group.
+func (c *BinpackingCollector) emitNodeStateMetrics(ch chan<- prometheus.Metric, usages []*nodeUsage, resources []corev1.ResourceName, groupLabels ...string) {
+	countDesc, allocatedDesc, allocatableDesc := clusterNodeStateCount, clusterNodeStateAllocated, clusterNodeStateAllocatable
+	if len(groupLabels) > 0 {
+		countDesc, allocatedDesc, allocatableDesc = groupNodeStateCount, groupNodeStateAllocated, groupNodeStateAllocatable
+	}
+
+	byState := make(map[string][]*nodeUsage)
+	for _, usage := range usages {
+		byState[usage.state] = append(byState[usage.state], usage)
+	}
+
+	for _, state := range nodeStates {
+		ch <- prometheus.MustNewConstMetric(countDesc, prometheus.GaugeValue, float64(len(byState[state])), append(groupLabels, state)...)
+		if c.opts.NodeStateMode != NodeStateSeparate {
+			continue
+		}
+		totals := sumNodeUsage(byState[state])
+		for _, res := range resources {
+			labelValues := append(slices.Clone(groupLabels), string(res), state)
+			ch <- prometheus.MustNewConstMetric(allocatedDesc, prometheus.GaugeValue, totals[res].allocated, labelValues...)
+			ch <- prometheus.MustNewConstMetric(allocatableDesc, prometheus.GaugeValue, totals[res].allocatable, labelValues...)
+		}
+	}
+}
+
+// sumNodeUsage adds up per-node accounting into per-resource totals.
+func sumNodeUsage(usages []*nodeUsage) map[corev1.ResourceName]resourceUsage {
+	totals := make(map[corev1.ResourceName]resourceUsage)
+	for _, usage := range usages {
+		for res, u := range usage.resources {
+			t := totals[res]
+			t.add(u)
+			totals[res] = t
+		}
+	}
+	return totals
+}
+
+// utilizationHistogram returns the distribution of the nodes' utilization
+// ratio of the resource as a classic or native histogram, depending on the
+// configured mode. Nodes without allocatable for the resource are left out.
+func (c *BinpackingCollector) utilizationHistogram(desc *prometheus.Desc, usages []*nodeUsage, res corev1.ResourceName, labelValues ...string) prometheus.Metric {
+	ratios := nodeUtilizationRatios(usages, res)
+
+	var sum float64
+	for _, r := range ratios {
+		sum += r
+	}
+	count := uint64(len(ratios))
+
+	if c.opts.UtilizationHistogram == HistogramNative {
+		positive := make(map[int]int64)
+		var zero uint64
+		for _, r := range ratios {
+			if r <= prometheus.DefNativeHistogramZeroThreshold {
+				zero++
+				continue
+			}
+			positive[nativeBucketIndex(r, nativeHistogramSchema)]++
+		}
+		return prometheus.MustNewConstNativeHistogram(desc, count, sum, positive, nil, zero,
+			nativeHistogramSchema, prometheus.DefNativeHistogramZeroThreshold, time.Time{}, labelValues...)
+	}
+
+	buckets := make(map[float64]uint64, len(utilizationBuckets))
+	for _, upper := range utilizationBuckets {
+		buckets[upper] = 0
+		for _, r := range ratios {
+			if r <= upper {
+				buckets[upper]++
+			}
+		}
+	}
+	return prometheus.MustNewConstHistogram(desc, count, sum, buckets, labelValues...)
+}
+
+// nodeUtilizationRatios returns the utilization ratio of the resource on each
+// node that has allocatable for it.
+func nodeUtilizationRatios(usages []*nodeUsage, res corev1.ResourceName) []float64 {
+	ratios := make([]float64, 0, len(usages))
+	for _, usage := range usages {
+		if u := usage.resources[res]; u.allocatable > 0 {
+			ratios = append(ratios, u.allocated/u.allocatable)
+		}
+	}
+	return ratios
+}
+
+// quantile returns the q-quantile of the sorted, non-empty values, linearly
+// interpolating between the closest ranks.
+func quantile(sorted []float64, q float64) float64 {
+	pos := q * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	upper := int(math.Ceil(pos))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(pos-float64(lower))
+}
+
+// stddev returns the population standard deviation of the values.
+func stddev(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+
+	var sq float64
+	for _, v := range values {
+		sq += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(sq / float64(len(values)))
+}
+
+// nativeBucketIndex returns the index of the native histogram bucket holding
+// the positive value v: bucket i covers (base^(i-1), base^i] with
+// base = 2^(2^-schema).
+func nativeBucketIndex(v float64, schema int32) int {
+	frac, exp := math.Frexp(v) // v = frac * 2^exp, frac in [0.5, 1)
+	if frac == 0.5 {
+		// Exact powers of two are the upper bound of their bucket.
+		return (exp - 1) << schema
+	}
+	return int(math.Ceil((math.Log2(frac) + float64(exp)) * math.Exp2(float64(schema))))
+}
+
+// fragmentation describes how the free (unallocated) amount of a resource is
+// spread across a set of nodes.
+type fragmentation struct {
+	largestFree float64 // most free on a single node
+	totalFree   float64 // free summed over all nodes
+}
+
+// index returns 1 - largestFree/totalFree: 0 when all free resource sits on a
+// single node, approaching 1 as it is scattered in small pieces. A set of
+// nodes without free resource is not fragmented.
+func (f fragmentation) index() float64 {
+	if f.totalFree <= 0 {
+		return 0
+	}
+	return 1 - f.largestFree/f.totalFree
+}
+
+// computeFragmentation returns the free-resource fragmentation over the nodes.
+// Over-allocated nodes count as having nothing free.
+func computeFragmentation(usages []*nodeUsage, res corev1.ResourceName) fragmentation {
+	var f fragmentation
+	for _, usage := range usages {
+		u := usage.resources[res]
+		free := max(u.allocatable-u.allocated, 0)
+		f.largestFree = max(f.largestFree, free)
+		f.totalFree += free
+	}
+	return f
+}
+
+// nodesRequired simulates repacking the pods of the nodes, other than
+// DaemonSet and static pods, onto as few of them as possible. Each node
+// offers its allocatable minus its own DaemonSet and static pod overhead; if
+// the pods do not fit, extra nodes the size of the largest one are added.
+// Resources no node offers are ignored.
+//
+// lowerBound is the fewest nodes whose combined free capacity covers the
+// total demand of every resource. firstFit places pods largest-first into the
+// first node they fit, trying the largest nodes first, so it accounts for
+// pods not being divisible across nodes. Nodes that cannot fit the smallest
+// remaining pod are dropped from the search, which keeps it well below
+// pods × nodes for typical workloads.
+func nodesRequired(usages []*nodeUsage, resources []corev1.ResourceName) (lowerBound, firstFit int) {
+	nodeFree := func(usage *nodeUsage, res corev1.ResourceName) float64 {
+		u := usage.resources[res]
+		return max(u.allocatable-u.daemonsetOverhead-u.staticPodOverhead, 0)
+	}
+
+	var offered []corev1.ResourceName
+	var largest []float64
+	for _, res := range resources {
+		var l float64
+		for _, usage := range usages {
+			l = max(l, nodeFree(usage, res))
+		}
+		if l > 0 {
+			offered = append(offered, res)
+			largest = append(largest, l)
+		}
+	}
+
+	// Bins and pods are resource vectors indexed like offered, with a size
+	// normalized to the largest node so they can be ordered by one number.
+	type item struct {
+		name string
+		v    []float64
+		size float64
+	}
+	newItem := func(name string, v []float64) item {
+		it := item{name: name, v: v}
+		for r := range offered {
+			it.size += v[r] / largest[r]
+		}
+		return it
+	}
+	bins := make([]item, 0, len(usages))
+	var pods []item
+	for _, usage := range usages {
+		free := make([]float64, len(offered))
+		for r, res := range offered {
+			free[r] = nodeFree(usage, res)
+		}
+		bins = append(bins, newItem(usage.node.Name, free))
+		for _, requests := range usage.workloadRequests {
+			v := make([]float64, len(offered))
+			for r, res := range offered {
+				v[r] = requests[res]
+			}
+			if p := newItem("", v); p.size > 0 {
+				pods = append(pods, p)
+			}
+		}
+	}
+	slices.SortStableFunc(bins, func(a, b item) int {
+		if c := cmp.Compare(b.size, a.size); c != 0 {
+			return c
 		}
+		return strings.Compare(a.name, b.name)
+	})
+	slices.SortStableFunc(pods, func(a, b item) int { return cmp.Compare(b.size, a.size) })
+
+	for r := range offered {
+		var demand float64
+		for _, p := range pods {
+			demand += p.v[r]
+		}
+		free := make([]float64, len(bins))
+		for i, b := range bins {
+			free[i] = b.v[r]
+		}
+		slices.SortFunc(free, func(a, b float64) int { return cmp.Compare(b, a) })
+
+		var n int
+		for covered := 0.0; covered < demand; n++ {
+			if n < len(free) {
+				covered += free[n]
+			} else {
+				covered += largest[r]
+			}
+		}
+		lowerBound = max(lowerBound, n)
+	}
+
+	fits := func(free, request []float64) bool {
+		for r := range request {
+			if request[r]-free[r] > 1e-9 {
+				return false
+			}
+		}
+		return true
+	}
+
+	// minRemaining[k*len(offered)+r] is the smallest request of resource r
+	// among pods[k:]. A bin that cannot fit it is full for the rest of the
+	// simulation and is dropped from the search.
+	minRemaining := make([]float64, len(pods)*len(offered))
+	for k := len(pods) - 1; k >= 0; k-- {
+		for r := range offered {
+			m := pods[k].v[r]
+			if k+1 < len(pods) {
+				m = min(m, minRemaining[(k+1)*len(offered)+r])
+			}
+			minRemaining[k*len(offered)+r] = m
+		}
+	}
+
+	// open holds the indexes of bins that can still take a pod, in first-fit
+	// order. Replicas with identical requests resume the search where the
+	// previous one was placed, since the bins before it did not fit and are
+	// unchanged.
+	open := make([]int, len(bins))
+	for i := range open {
+		open[i] = i
 	}
+	used := make([]bool, len(bins))
+	start := 0
+	for k, p := range pods {
+		if k == 0 || !slices.Equal(p.v, pods[k-1].v) {
+			start = 0
+		}
+		smallest := minRemaining[k*len(offered) : (k+1)*len(offered)]
+
+		found := -1
+		w := start
+		for j := start; j < len(open); j++ {
+			b := open[j]
+			if !fits(bins[b].v, smallest) {
+				continue
+			}
+			open[w] = b
+			if fits(bins[b].v, p.v) {
+				found = w
+				w++
+				w += copy(open[w:], open[j+1:])
+				break
+			}
+			w++
+		}
+		open = open[:w]
+		if found < 0 {
+			// Add a node the size of the largest; a pod bigger than that
+			// gets a node to itself.
+			bins = append(bins, item{v: slices.Clone(largest)})
+			used = append(used, false)
+			open = append(open, len(bins)-1)
+			found = len(open) - 1
+		}
+
+		b := open[found]
+		for r := range offered {
+			bins[b].v[r] -= p.v[r]
+		}
+		used[b] = true
+		start = found
+	}
+	for _, u := range used {
+		if u {
+			firstFit++
+		}
+	}
+
+	return lowerBound, firstFit
+}
+
+// safeRatio returns num/den, or 0 when den is not positive.
+func safeRatio(num, den float64) float64 {
+	if den > 0 {
+		return num / den
+	}
+	return 0
 }
 
 func boolToFloat64(b bool) float64 {
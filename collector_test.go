@@ -1,14 +1,20 @@
 package main
 
 import (
+	"bytes"
 	"log/slog"
 	"math"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -168,1357 +174,4052 @@ func TestCalculatePodRequest(t *testing.T) {
 	}
 }
 
-// Helper function to create a pod with specified resources.
-// This will be useful for all pod-related tests.
-func makePodWithResources(
-	namespace, name, nodeName string,
-	phase corev1.PodPhase,
-	containers []corev1.Container,
-	initContainers []corev1.Container,
-) *corev1.Pod {
-	return &corev1.Pod{
-		ObjectMeta: metav1.ObjectMeta{
-			Namespace: namespace,
-			Name:      name,
+// TestCalculatePodRequest_Sidecars tests native sidecar (restartable init
+// container) accounting. Cases mirror the restartable init container cases of
+// the upstream resource helpers (k8s.io/component-helpers/resource).
+func TestCalculatePodRequest_Sidecars(t *testing.T) {
+	tests := []struct {
+		name           string
+		containers     []corev1.Container
+		initContainers []corev1.Container
+		wantValue      float64
+		wantSidecarSum float64
+		wantUsedInit   bool
+	}{
+		{
+			name:           "restartable init container",
+			containers:     []corev1.Container{makeContainer("app", "1", "")},
+			initContainers: []corev1.Container{makeSidecarContainer("sidecar", "1", "")},
+			wantValue:      2, // sidecar keeps running next to app
+			wantSidecarSum: 1,
 		},
-		Spec: corev1.PodSpec{
-			NodeName:       nodeName,
-			Containers:     containers,
-			InitContainers: initContainers,
+		{
+			name:       "multiple restartable init containers",
+			containers: []corev1.Container{makeContainer("app", "1", "")},
+			initContainers: []corev1.Container{
+				makeSidecarContainer("sidecar-1", "1", ""),
+				makeSidecarContainer("sidecar-2", "2", ""),
+				makeSidecarContainer("sidecar-3", "3", ""),
+			},
+			wantValue:      7, // 1 + 2 + 3 + 1
+			wantSidecarSum: 6,
 		},
-		Status: corev1.PodStatus{
-			Phase: phase,
+		{
+			name:       "multiple restartable and regular init containers",
+			containers: []corev1.Container{makeContainer("app", "1", "")},
+			initContainers: []corev1.Container{
+				makeContainer("init-1", "5", ""),
+				makeSidecarContainer("sidecar-1", "1", ""),
+				makeSidecarContainer("sidecar-2", "2", ""),
+				makeContainer("init-2", "5", ""),
+				makeSidecarContainer("sidecar-3", "3", ""),
+			},
+			wantValue:      8, // init-2 runs next to sidecar-1 and sidecar-2: 5 + 1 + 2
+			wantSidecarSum: 6,
+			wantUsedInit:   true,
 		},
+		{
+			name:       "regular init container before sidecars does not see them",
+			containers: []corev1.Container{makeContainer("app", "1", "")},
+			initContainers: []corev1.Container{
+				makeContainer("init-1", "3", ""),
+				makeSidecarContainer("sidecar-1", "1", ""),
+			},
+			wantValue:      3, // max(init-1, app + sidecar-1)
+			wantSidecarSum: 1,
+			wantUsedInit:   true,
+		},
+		{
+			name:       "restartable init container with heavy regular containers",
+			containers: []corev1.Container{makeContainer("app", "10", "")},
+			initContainers: []corev1.Container{
+				makeSidecarContainer("sidecar-1", "1", ""),
+				makeContainer("init-1", "5", ""),
+			},
+			wantValue:      11, // app + sidecar-1 dominates init-1 + sidecar-1
+			wantSidecarSum: 1,
+		},
+		{
+			name:       "restartable init container without requests",
+			containers: []corev1.Container{makeContainer("app", "1", "")},
+			initContainers: []corev1.Container{
+				makeSidecarContainer("sidecar-1", "", "64Mi"),
+				makeContainer("init-1", "2", ""),
+			},
+			wantValue:    2,
+			wantUsedInit: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := makePodWithResources("default", "test-pod", "test-node", corev1.PodRunning,
+				tt.containers, tt.initContainers)
+
+			gotValue, details := calculatePodRequest(pod, corev1.ResourceCPU)
+			if !floatEquals(gotValue, tt.wantValue) {
+				t.Errorf("calculatePodRequest() value = %v, want %v", gotValue, tt.wantValue)
+			}
+			if !floatEquals(details.sidecarSum, tt.wantSidecarSum) {
+				t.Errorf("details.sidecarSum = %v, want %v", details.sidecarSum, tt.wantSidecarSum)
+			}
+			if details.usedInit != tt.wantUsedInit {
+				t.Errorf("details.usedInit = %v, want %v", details.usedInit, tt.wantUsedInit)
+			}
+			if !tt.wantUsedInit && !floatEquals(details.regularSum+details.sidecarSum, gotValue) {
+				t.Errorf("when usedInit=false, regularSum+sidecarSum=%v should equal effective=%v",
+					details.regularSum+details.sidecarSum, gotValue)
+			}
+		})
 	}
 }
 
-// Helper to create a container with resource requests.
-func makeContainer(name string, cpu, memory string) corev1.Container {
-	container := corev1.Container{
-		Name: name,
-		Resources: corev1.ResourceRequirements{
-			Requests: corev1.ResourceList{},
+// TestCalculatePodRequest_PodLevelResources tests that pod-level requests
+// (spec.resources) replace container math for supported resources.
+func TestCalculatePodRequest_PodLevelResources(t *testing.T) {
+	tests := []struct {
+		name           string
+		containers     []corev1.Container
+		initContainers []corev1.Container
+		podRequests    corev1.ResourceList
+		overhead       corev1.ResourceList
+		resource       corev1.ResourceName
+		wantValue      float64
+		wantPodLevel   bool
+	}{
+		{
+			name:       "pod-level cpu replaces container sum",
+			containers: []corev1.Container{makeContainer("app", "100m", ""), makeContainer("sidecar", "100m", "")},
+			podRequests: corev1.ResourceList{
+				corev1.ResourceCPU: resource.MustParse("1"),
+			},
+			resource:     corev1.ResourceCPU,
+			wantValue:    1,
+			wantPodLevel: true,
+		},
+		{
+			name:           "pod-level cpu wins over dominant init container",
+			containers:     []corev1.Container{makeContainer("app", "100m", "")},
+			initContainers: []corev1.Container{makeContainer("init", "2", "")},
+			podRequests: corev1.ResourceList{
+				corev1.ResourceCPU: resource.MustParse("1"),
+			},
+			resource:     corev1.ResourceCPU,
+			wantValue:    1,
+			wantPodLevel: true,
+		},
+		{
+			name:       "resource without pod-level request falls back to containers",
+			containers: []corev1.Container{makeContainer("app", "100m", "128Mi")},
+			podRequests: corev1.ResourceList{
+				corev1.ResourceCPU: resource.MustParse("1"),
+			},
+			resource:  corev1.ResourceMemory,
+			wantValue: 128 * 1024 * 1024,
+		},
+		{
+			name:       "unsupported pod-level resource is ignored",
+			containers: []corev1.Container{makeContainer("app", "100m", "")},
+			podRequests: corev1.ResourceList{
+				corev1.ResourceEphemeralStorage: resource.MustParse("1Gi"),
+			},
+			resource:  corev1.ResourceEphemeralStorage,
+			wantValue: 0,
+		},
+		{
+			name:       "overhead added on top of pod-level request",
+			containers: []corev1.Container{makeContainer("app", "100m", "")},
+			podRequests: corev1.ResourceList{
+				corev1.ResourceCPU: resource.MustParse("1"),
+			},
+			overhead: corev1.ResourceList{
+				corev1.ResourceCPU: resource.MustParse("250m"),
+			},
+			resource:     corev1.ResourceCPU,
+			wantValue:    1.25,
+			wantPodLevel: true,
 		},
 	}
 
-	if cpu != "" {
-		container.Resources.Requests[corev1.ResourceCPU] = resource.MustParse(cpu)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := makePodWithResources("default", "test-pod", "test-node", corev1.PodRunning,
+				tt.containers, tt.initContainers)
+			pod.Spec.Resources = &corev1.ResourceRequirements{Requests: tt.podRequests}
+			pod.Spec.Overhead = tt.overhead
+
+			gotValue, details := calculatePodRequest(pod, tt.resource)
+			if !floatEquals(gotValue, tt.wantValue) {
+				t.Errorf("calculatePodRequest() value = %v, want %v", gotValue, tt.wantValue)
+			}
+			if details.podLevel != tt.wantPodLevel {
+				t.Errorf("details.podLevel = %v, want %v", details.podLevel, tt.wantPodLevel)
+			}
+			if details.podLevel && details.usedInit {
+				t.Error("details.usedInit should be false when pod-level requests are used")
+			}
+		})
 	}
-	if memory != "" {
-		container.Resources.Requests[corev1.ResourceMemory] = resource.MustParse(memory)
+}
+
+// TestCalculatePodRequest_Resize tests that in-place pod resizes are accounted
+// like the scheduler does: max of desired, actuated and allocated requests, or
+// only actuated/allocated when the resize is infeasible.
+func TestCalculatePodRequest_Resize(t *testing.T) {
+	tests := []struct {
+		name           string
+		containers     []corev1.Container
+		initContainers []corev1.Container
+		statuses       []corev1.ContainerStatus
+		initStatuses   []corev1.ContainerStatus
+		conditions     []corev1.PodCondition
+		wantValue      float64
+	}{
+		{
+			name:       "no container status uses spec",
+			containers: []corev1.Container{makeContainer("app", "1", "")},
+			wantValue:  1,
+		},
+		{
+			name:       "status without resources uses spec",
+			containers: []corev1.Container{makeContainer("app", "1", "")},
+			statuses:   []corev1.ContainerStatus{{Name: "app"}},
+			wantValue:  1,
+		},
+		{
+			name:       "resize up pending reserves desired",
+			containers: []corev1.Container{makeContainer("app", "2", "")},
+			statuses:   []corev1.ContainerStatus{makeResizeStatus("app", "1", "1")},
+			conditions: []corev1.PodCondition{makeResizeCondition(corev1.PodResizePending, corev1.PodReasonDeferred)},
+			wantValue:  2,
+		},
+		{
+			name:       "resize down in progress reserves actuated",
+			containers: []corev1.Container{makeContainer("app", "1", "")},
+			statuses:   []corev1.ContainerStatus{makeResizeStatus("app", "2", "1")},
+			conditions: []corev1.PodCondition{makeResizeCondition(corev1.PodResizeInProgress, "")},
+			wantValue:  2,
+		},
+		{
+			name:       "allocated larger than actuated",
+			containers: []corev1.Container{makeContainer("app", "1", "")},
+			statuses:   []corev1.ContainerStatus{makeResizeStatus("app", "1", "3")},
+			conditions: []corev1.PodCondition{makeResizeCondition(corev1.PodResizeInProgress, "")},
+			wantValue:  3,
+		},
+		{
+			name:       "infeasible resize ignores desired",
+			containers: []corev1.Container{makeContainer("app", "4", "")},
+			statuses:   []corev1.ContainerStatus{makeResizeStatus("app", "1", "1")},
+			conditions: []corev1.PodCondition{makeResizeCondition(corev1.PodResizePending, corev1.PodReasonInfeasible)},
+			wantValue:  1,
+		},
+		{
+			name:           "sidecar status is used",
+			containers:     []corev1.Container{makeContainer("app", "1", "")},
+			initContainers: []corev1.Container{makeSidecarContainer("sidecar", "500m", "")},
+			initStatuses:   []corev1.ContainerStatus{makeResizeStatus("sidecar", "1", "1")},
+			wantValue:      2,
+		},
+		{
+			name:           "regular init container status is ignored",
+			containers:     []corev1.Container{makeContainer("app", "1", "")},
+			initContainers: []corev1.Container{makeContainer("init", "500m", "")},
+			initStatuses:   []corev1.ContainerStatus{makeResizeStatus("init", "4", "4")},
+			wantValue:      1,
+		},
 	}
 
-	return container
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := makePodWithResources("default", "test-pod", "test-node", corev1.PodRunning,
+				tt.containers, tt.initContainers)
+			pod.Status.ContainerStatuses = tt.statuses
+			pod.Status.InitContainerStatuses = tt.initStatuses
+			pod.Status.Conditions = tt.conditions
+
+			gotValue, _ := calculatePodRequest(pod, corev1.ResourceCPU)
+			if !floatEquals(gotValue, tt.wantValue) {
+				t.Errorf("calculatePodRequest() value = %v, want %v", gotValue, tt.wantValue)
+			}
+		})
+	}
 }
 
-// Helper to create a node with allocatable resources.
-func makeNode(name string, cpu, memory string) *corev1.Node {
-	node := &corev1.Node{
-		ObjectMeta: metav1.ObjectMeta{
-			Name: name,
+// TestPodResizeState tests mapping of resize conditions to resize states.
+func TestPodResizeState(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions []corev1.PodCondition
+		want       string
+	}{
+		{
+			name: "no conditions",
+			want: "",
 		},
-		Status: corev1.NodeStatus{
-			Allocatable: corev1.ResourceList{},
+		{
+			name:       "unrelated condition",
+			conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+			want:       "",
+		},
+		{
+			name:       "in progress",
+			conditions: []corev1.PodCondition{makeResizeCondition(corev1.PodResizeInProgress, "")},
+			want:       resizeStateInProgress,
+		},
+		{
+			name:       "deferred",
+			conditions: []corev1.PodCondition{makeResizeCondition(corev1.PodResizePending, corev1.PodReasonDeferred)},
+			want:       resizeStateDeferred,
+		},
+		{
+			name:       "infeasible",
+			conditions: []corev1.PodCondition{makeResizeCondition(corev1.PodResizePending, corev1.PodReasonInfeasible)},
+			want:       resizeStateInfeasible,
+		},
+		{
+			name: "pending takes precedence over in progress",
+			conditions: []corev1.PodCondition{
+				makeResizeCondition(corev1.PodResizeInProgress, ""),
+				makeResizeCondition(corev1.PodResizePending, corev1.PodReasonDeferred),
+			},
+			want: resizeStateDeferred,
+		},
+		{
+			name: "condition not true is ignored",
+			conditions: []corev1.PodCondition{
+				{Type: corev1.PodResizeInProgress, Status: corev1.ConditionFalse},
+			},
+			want: "",
 		},
 	}
 
-	if cpu != "" {
-		node.Status.Allocatable[corev1.ResourceCPU] = resource.MustParse(cpu)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &corev1.Pod{Status: corev1.PodStatus{Conditions: tt.conditions}}
+			if got := podResizeState(pod); got != tt.want {
+				t.Errorf("podResizeState() = %q, want %q", got, tt.want)
+			}
+		})
 	}
-	if memory != "" {
-		node.Status.Allocatable[corev1.ResourceMemory] = resource.MustParse(memory)
+}
+
+// TestCalculatePodRequest_Overhead tests that RuntimeClass pod overhead is added
+// on top of the container requests, matching scheduler accounting.
+func TestCalculatePodRequest_Overhead(t *testing.T) {
+	tests := []struct {
+		name           string
+		containers     []corev1.Container
+		initContainers []corev1.Container
+		overhead       corev1.ResourceList
+		resource       corev1.ResourceName
+		wantValue      float64
+		wantOverhead   float64
+	}{
+		{
+			name:       "no overhead",
+			containers: []corev1.Container{makeContainer("app", "100m", "128Mi")},
+			resource:   corev1.ResourceCPU,
+			wantValue:  0.1,
+		},
+		{
+			name:       "overhead added to regular containers",
+			containers: []corev1.Container{makeContainer("app", "100m", "128Mi")},
+			overhead: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("250m"),
+				corev1.ResourceMemory: resource.MustParse("120Mi"),
+			},
+			resource:     corev1.ResourceCPU,
+			wantValue:    0.35, // 100m + 250m overhead
+			wantOverhead: 0.25,
+		},
+		{
+			name:           "overhead added when init container dominates",
+			containers:     []corev1.Container{makeContainer("app", "100m", "128Mi")},
+			initContainers: []corev1.Container{makeContainer("init", "500m", "64Mi")},
+			overhead: corev1.ResourceList{
+				corev1.ResourceCPU: resource.MustParse("250m"),
+			},
+			resource:     corev1.ResourceCPU,
+			wantValue:    0.75, // max(100m, 500m) + 250m overhead
+			wantOverhead: 0.25,
+		},
+		{
+			name:       "overhead for another resource is ignored",
+			containers: []corev1.Container{makeContainer("app", "100m", "128Mi")},
+			overhead: corev1.ResourceList{
+				corev1.ResourceMemory: resource.MustParse("120Mi"),
+			},
+			resource:  corev1.ResourceCPU,
+			wantValue: 0.1,
+		},
+		{
+			name:       "overhead on pod without container requests",
+			containers: []corev1.Container{makeContainer("app", "", "")},
+			overhead: corev1.ResourceList{
+				corev1.ResourceMemory: resource.MustParse("120Mi"),
+			},
+			resource:     corev1.ResourceMemory,
+			wantValue:    120 * 1024 * 1024,
+			wantOverhead: 120 * 1024 * 1024,
+		},
 	}
 
-	return node
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := makePodWithResources("default", "test-pod", "test-node", corev1.PodRunning,
+				tt.containers, tt.initContainers)
+			pod.Spec.Overhead = tt.overhead
+
+			gotValue, details := calculatePodRequest(pod, tt.resource)
+			if !floatEquals(gotValue, tt.wantValue) {
+				t.Errorf("calculatePodRequest() value = %v, want %v", gotValue, tt.wantValue)
+			}
+			if !floatEquals(details.overhead, tt.wantOverhead) {
+				t.Errorf("details.overhead = %v, want %v", details.overhead, tt.wantOverhead)
+			}
+			if !floatEquals(details.effective, gotValue) {
+				t.Errorf("details.effective = %v, but returned value = %v", details.effective, gotValue)
+			}
+		})
+	}
 }
 
-// Mock node lister for testing.
-type fakeNodeLister struct {
-	nodes []*corev1.Node
-	err   error // error to return from List()
+// TestCalculatePodRequest_Pods tests that every pod counts as one pod slot,
+// regardless of its containers.
+func TestCalculatePodRequest_Pods(t *testing.T) {
+	tests := []struct {
+		name           string
+		containers     []corev1.Container
+		initContainers []corev1.Container
+		overhead       corev1.ResourceList
+	}{
+		{
+			name:       "single container",
+			containers: []corev1.Container{makeContainer("app", "100m", "128Mi")},
+		},
+		{
+			name: "multiple containers and init containers",
+			containers: []corev1.Container{
+				makeContainer("app", "100m", "128Mi"),
+				makeContainer("proxy", "50m", "64Mi"),
+			},
+			initContainers: []corev1.Container{
+				makeContainer("init", "500m", "256Mi"),
+				makeSidecarContainer("sidecar", "50m", "32Mi"),
+			},
+		},
+		{
+			name:       "no requests",
+			containers: []corev1.Container{makeContainer("app", "", "")},
+		},
+		{
+			name:       "overhead does not add slots",
+			containers: []corev1.Container{makeContainer("app", "100m", "128Mi")},
+			overhead:   corev1.ResourceList{corev1.ResourcePods: resource.MustParse("1")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := makePodWithResources("default", "test-pod", "test-node", corev1.PodRunning,
+				tt.containers, tt.initContainers)
+			pod.Spec.Overhead = tt.overhead
+
+			gotValue, details := calculatePodRequest(pod, corev1.ResourcePods)
+			if !floatEquals(gotValue, 1) {
+				t.Errorf("calculatePodRequest() value = %v, want 1", gotValue)
+			}
+			if !floatEquals(details.effective, gotValue) {
+				t.Errorf("details.effective = %v, but returned value = %v", details.effective, gotValue)
+			}
+		})
+	}
+}
+
+// TestCalculatePodLimit tests the effective pod limit and whether it is bounded.
+func TestCalculatePodLimit(t *testing.T) {
+	tests := []struct {
+		name           string
+		containers     []corev1.Container
+		initContainers []corev1.Container
+		podLimits      corev1.ResourceList
+		overhead       corev1.ResourceList
+		resource       corev1.ResourceName
+		wantLimit      float64
+		wantBounded    bool
+	}{
+		{
+			name:        "single container with limit",
+			containers:  []corev1.Container{withLimits(makeContainer("app", "100m", "128Mi"), "500m", "512Mi")},
+			resource:    corev1.ResourceCPU,
+			wantLimit:   0.5,
+			wantBounded: true,
+		},
+		{
+			name: "multiple containers summed",
+			containers: []corev1.Container{
+				withLimits(makeContainer("app", "100m", ""), "1", ""),
+				withLimits(makeContainer("proxy", "100m", ""), "500m", ""),
+			},
+			resource:    corev1.ResourceCPU,
+			wantLimit:   1.5,
+			wantBounded: true,
+		},
+		{
+			name: "container without limit is unbounded",
+			containers: []corev1.Container{
+				withLimits(makeContainer("app", "", "128Mi"), "", "512Mi"),
+				makeContainer("proxy", "", "64Mi"),
+			},
+			resource:    corev1.ResourceMemory,
+			wantLimit:   512 * 1024 * 1024,
+			wantBounded: false,
+		},
+		{
+			name:           "init container limit dominates",
+			containers:     []corev1.Container{withLimits(makeContainer("app", "100m", ""), "500m", "")},
+			initContainers: []corev1.Container{withLimits(makeContainer("init", "100m", ""), "2", "")},
+			resource:       corev1.ResourceCPU,
+			wantLimit:      2,
+			wantBounded:    true,
+		},
+		{
+			name:       "sidecar limit added to regular containers",
+			containers: []corev1.Container{withLimits(makeContainer("app", "100m", ""), "1", "")},
+			initContainers: []corev1.Container{
+				withLimits(makeSidecarContainer("proxy", "100m", ""), "500m", ""),
+			},
+			resource:    corev1.ResourceCPU,
+			wantLimit:   1.5,
+			wantBounded: true,
+		},
+		{
+			name:        "pod-level limit replaces container limits",
+			containers:  []corev1.Container{makeContainer("app", "100m", "")},
+			podLimits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+			resource:    corev1.ResourceCPU,
+			wantLimit:   2,
+			wantBounded: true,
+		},
+		{
+			name:        "overhead added to non-zero limit",
+			containers:  []corev1.Container{withLimits(makeContainer("app", "100m", ""), "1", "")},
+			overhead:    corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("250m")},
+			resource:    corev1.ResourceCPU,
+			wantLimit:   1.25,
+			wantBounded: true,
+		},
+		{
+			name:        "overhead not added without limits",
+			containers:  []corev1.Container{makeContainer("app", "100m", "")},
+			overhead:    corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("250m")},
+			resource:    corev1.ResourceCPU,
+			wantLimit:   0,
+			wantBounded: false,
+		},
+		{
+			name:        "extended resource without limit is bounded",
+			containers:  []corev1.Container{makeContainer("app", "100m", "")},
+			resource:    "nvidia.com/gpu",
+			wantLimit:   0,
+			wantBounded: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := makePodWithResources("default", "test-pod", "test-node", corev1.PodRunning,
+				tt.containers, tt.initContainers)
+			pod.Spec.Overhead = tt.overhead
+			if tt.podLimits != nil {
+				pod.Spec.Resources = &corev1.ResourceRequirements{Limits: tt.podLimits}
+			}
+
+			gotLimit, gotBounded := calculatePodLimit(pod, tt.resource)
+			if !floatEquals(gotLimit, tt.wantLimit) {
+				t.Errorf("calculatePodLimit() limit = %v, want %v", gotLimit, tt.wantLimit)
+			}
+			if gotBounded != tt.wantBounded {
+				t.Errorf("calculatePodLimit() bounded = %v, want %v", gotBounded, tt.wantBounded)
+			}
+		})
+	}
+}
+
+// Helper function to create a pod with specified resources.
+// This will be useful for all pod-related tests.
+func makePodWithResources(
+	namespace, name, nodeName string,
+	phase corev1.PodPhase,
+	containers []corev1.Container,
+	initContainers []corev1.Container,
+) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+		},
+		Spec: corev1.PodSpec{
+			NodeName:       nodeName,
+			Containers:     containers,
+			InitContainers: initContainers,
+		},
+		Status: corev1.PodStatus{
+			Phase: phase,
+		},
+	}
+}
+
+// Helper to create a container with resource requests.
+func makeContainer(name string, cpu, memory string) corev1.Container {
+	container := corev1.Container{
+		Name: name,
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{},
+		},
+	}
+
+	if cpu != "" {
+		container.Resources.Requests[corev1.ResourceCPU] = resource.MustParse(cpu)
+	}
+	if memory != "" {
+		container.Resources.Requests[corev1.ResourceMemory] = resource.MustParse(memory)
+	}
+
+	return container
+}
+
+// Helper to create a native sidecar (restartable init container) with resource requests.
+func makeSidecarContainer(name string, cpu, memory string) corev1.Container {
+	container := makeContainer(name, cpu, memory)
+	restartPolicy := corev1.ContainerRestartPolicyAlways
+	container.RestartPolicy = &restartPolicy
+	return container
+}
+
+// Helper to add resource limits to a container.
+func withLimits(container corev1.Container, cpu, memory string) corev1.Container {
+	container.Resources.Limits = corev1.ResourceList{}
+	if cpu != "" {
+		container.Resources.Limits[corev1.ResourceCPU] = resource.MustParse(cpu)
+	}
+	if memory != "" {
+		container.Resources.Limits[corev1.ResourceMemory] = resource.MustParse(memory)
+	}
+	return container
+}
+
+// Helper to create a container status with actuated and allocated CPU, as
+// reported by the kubelet for in-place pod resize.
+func makeResizeStatus(name string, actuatedCPU, allocatedCPU string) corev1.ContainerStatus {
+	return corev1.ContainerStatus{
+		Name: name,
+		Resources: &corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(actuatedCPU)},
+		},
+		AllocatedResources: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(allocatedCPU)},
+	}
+}
+
+// Helper to create a true in-place resize pod condition.
+func makeResizeCondition(condType corev1.PodConditionType, reason string) corev1.PodCondition {
+	return corev1.PodCondition{Type: condType, Status: corev1.ConditionTrue, Reason: reason}
+}
+
+// Helper to create a node with allocatable resources.
+func makeNode(name string, cpu, memory string) *corev1.Node {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Status: corev1.NodeStatus{
+			Allocatable: corev1.ResourceList{},
+		},
+	}
+
+	if cpu != "" {
+		node.Status.Allocatable[corev1.ResourceCPU] = resource.MustParse(cpu)
+	}
+	if memory != "" {
+		node.Status.Allocatable[corev1.ResourceMemory] = resource.MustParse(memory)
+	}
+
+	return node
+}
+
+// Mock node lister for testing.
+type fakeNodeLister struct {
+	nodes []*corev1.Node
+	err   error // error to return from List()
+}
+
+func (f *fakeNodeLister) List(selector labels.Selector) ([]*corev1.Node, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.nodes, nil
+}
+
+func (f *fakeNodeLister) Get(name string) (*corev1.Node, error) {
+	for _, node := range f.nodes {
+		if node.Name == name {
+			return node, nil
+		}
+	}
+	return nil, nil
+}
+
+// Mock pod lister for testing.
+type fakePodLister struct {
+	pods []*corev1.Pod
+	err  error // error to return from List()
+}
+
+func (f *fakePodLister) List(selector labels.Selector) ([]*corev1.Pod, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.pods, nil
+}
+
+func (f *fakePodLister) Pods(namespace string) listerscorev1.PodNamespaceLister {
+	return &fakePodNamespaceLister{pods: f.pods, namespace: namespace}
+}
+
+type fakePodNamespaceLister struct {
+	pods      []*corev1.Pod
+	namespace string
+}
+
+func (f *fakePodNamespaceLister) List(selector labels.Selector) ([]*corev1.Pod, error) {
+	var result []*corev1.Pod
+	for _, pod := range f.pods {
+		if pod.Namespace == f.namespace {
+			result = append(result, pod)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakePodNamespaceLister) Get(name string) (*corev1.Pod, error) {
+	for _, pod := range f.pods {
+		if pod.Namespace == f.namespace && pod.Name == name {
+			return pod, nil
+		}
+	}
+	return nil, nil
+}
+
+// TestBinpackingCollector_Collect tests the main collection logic.
+func TestBinpackingCollector_Collect(t *testing.T) {
+	// Create test nodes
+	nodes := []*corev1.Node{
+		makeNode("node-1", "4", "8Gi"),
+		makeNode("node-2", "8", "16Gi"),
+	}
+
+	// Create test pods
+	pods := []*corev1.Pod{
+		// node-1 pods (regular containers)
+		makePodWithResources("default", "pod-1", "node-1", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "1", "2Gi")}, nil),
+		makePodWithResources("default", "pod-2", "node-1", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "500m", "1Gi")}, nil),
+
+		// node-2 pods (with init containers)
+		makePodWithResources("default", "pod-3", "node-2", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "2", "4Gi")},
+			[]corev1.Container{makeContainer("init", "3", "6Gi")}), // init dominates
+
+		// Pods that should be filtered out
+		makePodWithResources("default", "unscheduled", "", corev1.PodPending,
+			[]corev1.Container{makeContainer("app", "1", "1Gi")}, nil), // no NodeName
+		makePodWithResources("default", "completed", "node-1", corev1.PodSucceeded,
+			[]corev1.Container{makeContainer("app", "1", "1Gi")}, nil), // terminated
+		makePodWithResources("default", "failed", "node-2", corev1.PodFailed,
+			[]corev1.Container{makeContainer("app", "1", "1Gi")}, nil), // terminated
+	}
+
+	// Create fake listers
+	nodeLister := &fakeNodeLister{nodes: nodes}
+	podLister := &fakePodLister{pods: pods}
+
+	// Create sync info
+	syncTime := time.Now().Add(-30 * time.Second)
+	syncInfo := &SyncInfo{
+		LastSyncTime: syncTime,
+	}
+
+	// Create logger (discard output for tests)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	// Create collector
+	resources := []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory}
+	collector := NewBinpackingCollector(nodeLister, podLister, logger, resources, nil, true, syncInfo, nil, CollectorOptions{})
+
+	// Collect metrics
+	ch := make(chan prometheus.Metric, 200)
+	collector.Collect(ch)
+	close(ch)
+
+	// Collect all metrics into a slice
+	var metrics []prometheus.Metric
+	for m := range ch {
+		metrics = append(metrics, m)
+	}
+
+	// Verify we got metrics
+	if len(metrics) == 0 {
+		t.Fatal("expected metrics but got none")
+	}
+
+	// Verify cache age metric exists and is approximately 30 seconds
+	foundCacheAge := false
+	for _, m := range metrics {
+		desc := m.Desc().String()
+		if contains(desc, "kube_binpacking_cache_age_seconds") {
+			foundCacheAge = true
+			// Note: Can't easily extract the value from prometheus.Metric without using testutil
+			break
+		}
+	}
+	if !foundCacheAge {
+		t.Error("expected kube_binpacking_cache_age_seconds metric but didn't find it")
+	}
+
+	// Count metric types
+	metricCounts := make(map[string]int)
+	for _, m := range metrics {
+		desc := m.Desc().String()
+		switch {
+		case contains(desc, "kube_binpacking_node_daemonset_overhead_ratio"):
+			metricCounts["node_daemonset_overhead_ratio"]++
+		case contains(desc, "kube_binpacking_node_daemonset_overhead"):
+			metricCounts["node_daemonset_overhead"]++
+		case contains(desc, "kube_binpacking_node_allocated"):
+			metricCounts["node_allocated"]++
+		case contains(desc, "kube_binpacking_node_allocatable"):
+			metricCounts["node_allocatable"]++
+		case contains(desc, "kube_binpacking_node_utilization_ratio"):
+			metricCounts["node_utilization"]++
+		case contains(desc, "kube_binpacking_cluster_daemonset_overhead_ratio"):
+			metricCounts["cluster_daemonset_overhead_ratio"]++
+		case contains(desc, "kube_binpacking_cluster_daemonset_overhead"):
+			metricCounts["cluster_daemonset_overhead"]++
+		case contains(desc, "kube_binpacking_cluster_allocated"):
+			metricCounts["cluster_allocated"]++
+		case contains(desc, "kube_binpacking_cluster_allocatable"):
+			metricCounts["cluster_allocatable"]++
+		case contains(desc, "kube_binpacking_cluster_utilization_ratio"):
+			metricCounts["cluster_utilization"]++
+		case contains(desc, "kube_binpacking_cache_age_seconds"):
+			metricCounts["cache_age"]++
+		}
+	}
+
+	// Verify metric counts
+	// 2 nodes × 2 resources = 4 metrics per type (node_allocated, node_allocatable, node_utilization, node_daemonset_overhead, node_daemonset_overhead_ratio)
+	// 2 resources = 2 metrics per type (cluster_allocated, cluster_allocatable, cluster_utilization, cluster_daemonset_overhead, cluster_daemonset_overhead_ratio)
+	// 1 cache_age metric
+	expectedCounts := map[string]int{
+		"node_allocated":                   4, // 2 nodes × 2 resources
+		"node_allocatable":                 4,
+		"node_utilization":                 4,
+		"node_daemonset_overhead":          4,
+		"node_daemonset_overhead_ratio":    4,
+		"cluster_allocated":                2, // 2 resources
+		"cluster_allocatable":              2,
+		"cluster_utilization":              2,
+		"cluster_daemonset_overhead":       2,
+		"cluster_daemonset_overhead_ratio": 2,
+		"cache_age":                        1,
+	}
+
+	for metricType, expected := range expectedCounts {
+		if metricCounts[metricType] != expected {
+			t.Errorf("metric %s: got %d, want %d", metricType, metricCounts[metricType], expected)
+		}
+	}
+}
+
+// TestBinpackingCollector_PodFiltering tests that unscheduled and terminated pods are filtered.
+func TestBinpackingCollector_PodFiltering(t *testing.T) {
+	// Create a single node
+	nodes := []*corev1.Node{
+		makeNode("node-1", "4", "8Gi"),
+	}
+
+	tests := []struct {
+		name          string
+		pod           *corev1.Pod
+		shouldInclude bool
+	}{
+		{
+			name:          "running pod on node",
+			pod:           makePodWithResources("default", "pod-1", "node-1", corev1.PodRunning, []corev1.Container{makeContainer("app", "1", "1Gi")}, nil),
+			shouldInclude: true,
+		},
+		{
+			name:          "pending pod on node",
+			pod:           makePodWithResources("default", "pod-2", "node-1", corev1.PodPending, []corev1.Container{makeContainer("app", "1", "1Gi")}, nil),
+			shouldInclude: true,
+		},
+		{
+			name:          "unscheduled pod (no NodeName)",
+			pod:           makePodWithResources("default", "pod-3", "", corev1.PodPending, []corev1.Container{makeContainer("app", "1", "1Gi")}, nil),
+			shouldInclude: false,
+		},
+		{
+			name:          "succeeded pod",
+			pod:           makePodWithResources("default", "pod-4", "node-1", corev1.PodSucceeded, []corev1.Container{makeContainer("app", "1", "1Gi")}, nil),
+			shouldInclude: false,
+		},
+		{
+			name:          "failed pod",
+			pod:           makePodWithResources("default", "pod-5", "node-1", corev1.PodFailed, []corev1.Container{makeContainer("app", "1", "1Gi")}, nil),
+			shouldInclude: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodeLister := &fakeNodeLister{nodes: nodes}
+			podLister := &fakePodLister{pods: []*corev1.Pod{tt.pod}}
+			logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+			resources := []corev1.ResourceName{corev1.ResourceCPU}
+
+			collector := NewBinpackingCollector(nodeLister, podLister, logger, resources, nil, true, nil, nil, CollectorOptions{})
+
+			ch := make(chan prometheus.Metric, 100)
+			collector.Collect(ch)
+			close(ch)
+
+			// Collect metrics
+			var metrics []prometheus.Metric
+			for m := range ch {
+				metrics = append(metrics, m)
+			}
+
+			// Check if there's a node_allocated metric with non-zero value
+			// If the pod should be included, we expect 1 CPU allocated
+			// If not, we expect 0 CPU allocated
+			foundNodeAllocated := false
+			for _, m := range metrics {
+				desc := m.Desc().String()
+				if contains(desc, "kube_binpacking_node_allocated") {
+					foundNodeAllocated = true
+					// We can't easily extract the exact value, but the test logic is correct
+				}
+			}
+
+			if !foundNodeAllocated {
+				t.Error("expected to find node_allocated metric")
+			}
+		})
+	}
+}
+
+// TestBinpackingCollector_Describe tests the Describe method.
+func TestBinpackingCollector_Describe(t *testing.T) {
+	nodeLister := &fakeNodeLister{nodes: nil}
+	podLister := &fakePodLister{pods: nil}
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	resources := []corev1.ResourceName{corev1.ResourceCPU}
+
+	collector := NewBinpackingCollector(nodeLister, podLister, logger, resources, nil, true, nil, nil, CollectorOptions{})
+
+	ch := make(chan *prometheus.Desc, 60)
+	collector.Describe(ch)
+	close(ch)
+
+	// Count descriptors
+	var descs []*prometheus.Desc
+	for d := range ch {
+		descs = append(descs, d)
+	}
+
+	// Should have 39 metric descriptors (16 node + 17 cluster + 1 cluster_node_count + 1 cluster_node_state_count + 3 pending + 1 cache_age)
+	// Node: allocated, allocatable, utilization, daemonset_overhead, daemonset_overhead_ratio, static_pod_overhead, static_pod_overhead_ratio,
+	// pod_overhead, terminating_allocated, resizing_pods, capacity, reserved_ratio, stranded, stranded_ratio, dominant_utilization_ratio, state
+	// Cluster: allocated, allocatable, utilization, daemonset_overhead, daemonset_overhead_ratio, static_pod_overhead, static_pod_overhead_ratio,
+	// pod_overhead, terminating_allocated, resizing_pods, capacity, reserved_ratio, stranded, stranded_ratio, dominant_utilization_ratio,
+	// largest_free, fragmentation_index
+	expectedDescCount := 39
+	if len(descs) != expectedDescCount {
+		t.Errorf("expected %d descriptors, got %d", expectedDescCount, len(descs))
+	}
+}
+
+// TestBinpackingCollector_ErrorHandling tests error cases in Collect.
+func TestBinpackingCollector_ErrorHandling(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	resources := []corev1.ResourceName{corev1.ResourceCPU}
+
+	t.Run("node lister error", func(t *testing.T) {
+		nodeLister := &fakeNodeLister{err: someError("node list failed")}
+		podLister := &fakePodLister{pods: []*corev1.Pod{}}
+		collector := NewBinpackingCollector(nodeLister, podLister, logger, resources, nil, true, nil, nil, CollectorOptions{})
+
+		ch := make(chan prometheus.Metric, 50)
+		collector.Collect(ch)
+		close(ch)
+
+		// Should not panic, but also won't emit node/cluster metrics (only cache age if syncInfo present)
+		// Collect all metrics
+		var count int
+		for range ch {
+			count++
+		}
+		// No metrics expected since node listing failed (except cache_age if syncInfo != nil)
+		if count != 0 {
+			t.Logf("Got %d metrics despite node list error (expected 0)", count)
+		}
+	})
+
+	t.Run("pod lister error", func(t *testing.T) {
+		nodes := []*corev1.Node{makeNode("node-1", "4", "8Gi")}
+		nodeLister := &fakeNodeLister{nodes: nodes}
+		podLister := &fakePodLister{err: someError("pod list failed")}
+		collector := NewBinpackingCollector(nodeLister, podLister, logger, resources, nil, true, nil, nil, CollectorOptions{})
+
+		ch := make(chan prometheus.Metric, 50)
+		collector.Collect(ch)
+		close(ch)
+
+		// Should not panic, returns early without emitting metrics
+		var count int
+		for range ch {
+			count++
+		}
+		// No metrics expected since pod listing failed (except cache_age if syncInfo != nil)
+		if count != 0 {
+			t.Logf("Got %d metrics despite pod list error (expected 0)", count)
+		}
+	})
+
+	t.Run("collect without syncInfo", func(t *testing.T) {
+		nodes := []*corev1.Node{makeNode("node-1", "4", "8Gi")}
+		pods := []*corev1.Pod{
+			makePodWithResources("default", "pod-1", "node-1", corev1.PodRunning,
+				[]corev1.Container{makeContainer("app", "1", "1Gi")}, nil),
+		}
+		nodeLister := &fakeNodeLister{nodes: nodes}
+		podLister := &fakePodLister{pods: pods}
+
+		// Create collector with nil syncInfo
+		collector := NewBinpackingCollector(nodeLister, podLister, logger, resources, nil, true, nil, nil, CollectorOptions{})
+
+		ch := make(chan prometheus.Metric, 50)
+		collector.Collect(ch)
+		close(ch)
+
+		// Should still emit metrics, just no cache_age
+		var count int
+		for range ch {
+			count++
+		}
+		// Should have node and cluster metrics (but no cache_age)
+		if count == 0 {
+			t.Error("Expected metrics to be emitted even without syncInfo")
+		}
+	})
+}
+
+// TestBinpackingCollector_DebugLogging tests debug logging paths.
+func TestBinpackingCollector_DebugLogging(t *testing.T) {
+	// Create logger with debug level enabled
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	resources := []corev1.ResourceName{corev1.ResourceCPU}
+
+	nodes := []*corev1.Node{makeNode("node-1", "4", "8Gi")}
+
+	// Pod where init container dominates (triggers debug log)
+	pods := []*corev1.Pod{
+		makePodWithResources("default", "pod-with-init", "node-1", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "100m", "128Mi")},
+			[]corev1.Container{makeContainer("init", "500m", "512Mi")}), // init dominates
+		// Pod with no resource requests (triggers different debug path)
+		makePodWithResources("default", "pod-no-requests", "node-1", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app2", "", "")}, nil),
+	}
+
+	nodeLister := &fakeNodeLister{nodes: nodes}
+	podLister := &fakePodLister{pods: pods}
+	collector := NewBinpackingCollector(nodeLister, podLister, logger, resources, nil, true, nil, nil, CollectorOptions{})
+
+	ch := make(chan prometheus.Metric, 100)
+	collector.Collect(ch)
+	close(ch)
+
+	// Verify metrics were collected (debug logging shouldn't break functionality)
+	var count int
+	for range ch {
+		count++
+	}
+	if count == 0 {
+		t.Error("Expected metrics to be collected with debug logging enabled")
+	}
+}
+
+// TestBinpackingCollector_ZeroAllocatable tests the edge case where a node has zero allocatable resources.
+func TestBinpackingCollector_ZeroAllocatable(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	resources := []corev1.ResourceName{corev1.ResourceCPU}
+
+	// Node with zero allocatable CPU
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Status: corev1.NodeStatus{
+			Allocatable: corev1.ResourceList{
+				// CPU is not set, so it's zero
+			},
+		},
+	}
+	nodes := []*corev1.Node{node}
+
+	pods := []*corev1.Pod{
+		makePodWithResources("default", "pod-1", "node-1", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "100m", "128Mi")}, nil),
+	}
+
+	nodeLister := &fakeNodeLister{nodes: nodes}
+	podLister := &fakePodLister{pods: pods}
+	collector := NewBinpackingCollector(nodeLister, podLister, logger, resources, nil, true, nil, nil, CollectorOptions{})
+
+	ch := make(chan prometheus.Metric, 100)
+	collector.Collect(ch)
+	close(ch)
+
+	// Collect metrics
+	var metrics []prometheus.Metric
+	for m := range ch {
+		metrics = append(metrics, m)
+	}
+
+	// Should still emit metrics, with 0 allocatable and undefined ratio
+	if len(metrics) == 0 {
+		t.Error("Expected metrics even with zero allocatable")
+	}
+}
+
+// TestBinpackingCollector_LabelGrouping tests the label-based combination grouping metrics.
+func TestBinpackingCollector_LabelGrouping(t *testing.T) {
+	// Create nodes with different label values
+	nodes := []*corev1.Node{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "node-zone-a-1",
+				Labels: map[string]string{
+					"topology.kubernetes.io/zone":      "us-east-1a",
+					"node.kubernetes.io/instance-type": "m5.large",
+				},
+			},
+			Status: corev1.NodeStatus{
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("4"),
+					corev1.ResourceMemory: resource.MustParse("8Gi"),
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "node-zone-a-2",
+				Labels: map[string]string{
+					"topology.kubernetes.io/zone":      "us-east-1a",
+					"node.kubernetes.io/instance-type": "m5.large",
+				},
+			},
+			Status: corev1.NodeStatus{
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("4"),
+					corev1.ResourceMemory: resource.MustParse("8Gi"),
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "node-zone-b-1",
+				Labels: map[string]string{
+					"topology.kubernetes.io/zone":      "us-east-1b",
+					"node.kubernetes.io/instance-type": "m5.xlarge",
+				},
+			},
+			Status: corev1.NodeStatus{
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("8"),
+					corev1.ResourceMemory: resource.MustParse("16Gi"),
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "node-no-zone",
+				Labels: map[string]string{
+					"node.kubernetes.io/instance-type": "m5.large",
+				},
+				// Note: missing zone label
+			},
+			Status: corev1.NodeStatus{
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("4"),
+					corev1.ResourceMemory: resource.MustParse("8Gi"),
+				},
+			},
+		},
+	}
+
+	// Create pods scheduled on different nodes
+	pods := []*corev1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod-1", Namespace: "default"},
+			Spec: corev1.PodSpec{
+				NodeName:   "node-zone-a-1",
+				Containers: []corev1.Container{makeContainer("app", "1000m", "2Gi")},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod-2", Namespace: "default"},
+			Spec: corev1.PodSpec{
+				NodeName:   "node-zone-a-2",
+				Containers: []corev1.Container{makeContainer("app", "2000m", "4Gi")},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod-3", Namespace: "default"},
+			Spec: corev1.PodSpec{
+				NodeName:   "node-zone-b-1",
+				Containers: []corev1.Container{makeContainer("app", "4000m", "8Gi")},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod-4", Namespace: "default"},
+			Spec: corev1.PodSpec{
+				NodeName:   "node-no-zone",
+				Containers: []corev1.Container{makeContainer("app", "500m", "1Gi")},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+	}
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	nodeLister := &fakeNodeLister{nodes: nodes}
+	podLister := &fakePodLister{pods: pods}
+
+	t.Run("single-key group", func(t *testing.T) {
+		// Group by zone only (single key per group)
+		labelGroups := [][]string{{"topology.kubernetes.io/zone"}}
+		resources := []corev1.ResourceName{corev1.ResourceCPU}
+
+		collector := NewBinpackingCollector(nodeLister, podLister, logger, resources, labelGroups, true, nil, nil, CollectorOptions{})
+
+		ch := make(chan prometheus.Metric, 200)
+		collector.Collect(ch)
+		close(ch)
+
+		var foundGroupMetric bool
+		for m := range ch {
+			desc := m.Desc().String()
+			if stringContains(desc, "kube_binpacking_group_allocated") {
+				foundGroupMetric = true
+			}
+			// Verify old metric names are NOT emitted
+			if stringContains(desc, "kube_binpacking_label_group_") {
+				t.Error("old metric name kube_binpacking_label_group_ should not be emitted")
+			}
+		}
+		if !foundGroupMetric {
+			t.Error("expected kube_binpacking_group_allocated metrics but got none")
+		}
+	})
+
+	t.Run("multi-key combination group", func(t *testing.T) {
+		// Group by zone AND instance-type (combination group)
+		labelGroups := [][]string{{"topology.kubernetes.io/zone", "node.kubernetes.io/instance-type"}}
+		resources := []corev1.ResourceName{corev1.ResourceCPU}
+
+		collector := NewBinpackingCollector(nodeLister, podLister, logger, resources, labelGroups, true, nil, nil, CollectorOptions{})
+
+		ch := make(chan prometheus.Metric, 200)
+		collector.Collect(ch)
+		close(ch)
+
+		var groupMetricCount int
+		for m := range ch {
+			desc := m.Desc().String()
+			if stringContains(desc, "kube_binpacking_group_allocated") {
+				groupMetricCount++
+			}
+		}
+		// 3 composite values: (us-east-1a,m5.large), (us-east-1b,m5.xlarge), (<none>,m5.large)
+		// × 1 resource = 3 allocated metrics
+		if groupMetricCount != 3 {
+			t.Errorf("expected 3 group_allocated metrics for composite group, got %d", groupMetricCount)
+		}
+	})
+
+	t.Run("multiple groups", func(t *testing.T) {
+		// Two separate groups: one single-key, one multi-key
+		labelGroups := [][]string{
+			{"topology.kubernetes.io/zone"},
+			{"topology.kubernetes.io/zone", "node.kubernetes.io/instance-type"},
+		}
+		resources := []corev1.ResourceName{corev1.ResourceCPU}
+
+		collector := NewBinpackingCollector(nodeLister, podLister, logger, resources, labelGroups, true, nil, nil, CollectorOptions{})
+
+		ch := make(chan prometheus.Metric, 300)
+		collector.Collect(ch)
+		close(ch)
+
+		var groupAllocatedCount, groupNodeCountMetrics int
+		for m := range ch {
+			desc := m.Desc().String()
+			if stringContains(desc, "kube_binpacking_group_allocated") {
+				groupAllocatedCount++
+			}
+			if stringContains(desc, "kube_binpacking_group_node_count") {
+				groupNodeCountMetrics++
+			}
+		}
+		// Group 1 (zone): 3 values × 1 res = 3 allocated
+		// Group 2 (zone,instance-type): 3 values × 1 res = 3 allocated
+		// Total: 6
+		if groupAllocatedCount != 6 {
+			t.Errorf("expected 6 group_allocated metrics for two groups, got %d", groupAllocatedCount)
+		}
+		// Group 1: 3 node_count + Group 2: 3 node_count = 6
+		if groupNodeCountMetrics != 6 {
+			t.Errorf("expected 6 group_node_count metrics for two groups, got %d", groupNodeCountMetrics)
+		}
+	})
+
+	t.Run("missing labels use none", func(t *testing.T) {
+		// node-no-zone is missing the zone label, should get <none>
+		labelGroups := [][]string{{"topology.kubernetes.io/zone"}}
+		resources := []corev1.ResourceName{corev1.ResourceCPU}
+
+		collector := NewBinpackingCollector(nodeLister, podLister, logger, resources, labelGroups, true, nil, nil, CollectorOptions{})
+
+		ch := make(chan prometheus.Metric, 200)
+		collector.Collect(ch)
+		close(ch)
+
+		var groupMetricCount int
+		for m := range ch {
+			desc := m.Desc().String()
+			if stringContains(desc, "kube_binpacking_group_node_count") {
+				groupMetricCount++
+			}
+		}
+		// 3 zone values: us-east-1a, us-east-1b, <none>
+		if groupMetricCount != 3 {
+			t.Errorf("expected 3 group_node_count metrics (including <none>), got %d", groupMetricCount)
+		}
+	})
+}
+
+// TestBinpackingCollector_DisableNodeMetrics tests that per-node metrics are not emitted when disabled.
+func TestBinpackingCollector_DisableNodeMetrics(t *testing.T) {
+	// Create test data
+	nodes := []*corev1.Node{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+			Status: corev1.NodeStatus{
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("4"),
+					corev1.ResourceMemory: resource.MustParse("8Gi"),
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-2"},
+			Status: corev1.NodeStatus{
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("4"),
+					corev1.ResourceMemory: resource.MustParse("8Gi"),
+				},
+			},
+		},
+	}
+
+	pods := []*corev1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod-1", Namespace: "default"},
+			Spec: corev1.PodSpec{
+				NodeName:   "node-1",
+				Containers: []corev1.Container{makeContainer("app", "1000m", "2Gi")},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+	}
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	nodeLister := &fakeNodeLister{nodes: nodes}
+	podLister := &fakePodLister{pods: pods}
+
+	resources := []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory}
+
+	// Create collector with node metrics DISABLED
+	collector := NewBinpackingCollector(nodeLister, podLister, logger, resources, nil, false, nil, nil, CollectorOptions{})
+
+	ch := make(chan prometheus.Metric, 100)
+	collector.Collect(ch)
+	close(ch)
+
+	// Count metric types
+	var nodeMetricCount, clusterMetricCount int
+	for m := range ch {
+		desc := m.Desc().String()
+		if stringContains(desc, "kube_binpacking_node_") {
+			nodeMetricCount++
+		}
+		if stringContains(desc, "kube_binpacking_cluster_") {
+			clusterMetricCount++
+		}
+	}
+
+	// Should have NO node metrics
+	if nodeMetricCount > 0 {
+		t.Errorf("Expected 0 node metrics when disabled, got %d", nodeMetricCount)
+	}
+
+	// Should still have cluster metrics (15 metrics × 2 resources + 3 resize states + 1 dominant + 1 node_count + 4 node states = 39)
+	expectedClusterMetrics := 39
+	if clusterMetricCount != expectedClusterMetrics {
+		t.Errorf("Expected %d cluster metrics, got %d", expectedClusterMetrics, clusterMetricCount)
+	}
+}
+
+// TestBinpackingCollector_EnableNodeMetrics tests that per-node metrics ARE emitted when enabled.
+func TestBinpackingCollector_EnableNodeMetrics(t *testing.T) {
+	// Create test data
+	nodes := []*corev1.Node{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+			Status: corev1.NodeStatus{
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU: resource.MustParse("4"),
+				},
+			},
+		},
+	}
+
+	pods := []*corev1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod-1", Namespace: "default"},
+			Spec: corev1.PodSpec{
+				NodeName:   "node-1",
+				Containers: []corev1.Container{makeContainer("app", "1000m", "2Gi")},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+	}
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	nodeLister := &fakeNodeLister{nodes: nodes}
+	podLister := &fakePodLister{pods: pods}
+
+	resources := []corev1.ResourceName{corev1.ResourceCPU}
+
+	// Create collector with node metrics ENABLED (default)
+	collector := NewBinpackingCollector(nodeLister, podLister, logger, resources, nil, true, nil, nil, CollectorOptions{})
+
+	ch := make(chan prometheus.Metric, 100)
+	collector.Collect(ch)
+	close(ch)
+
+	// Count metric types
+	var nodeMetricCount int
+	for m := range ch {
+		desc := m.Desc().String()
+		if stringContains(desc, "kube_binpacking_node_") {
+			nodeMetricCount++
+		}
+	}
+
+	// Should have node metrics (1 node × 13 metrics × 1 resource + 1 dominant + 1 state = 15, no resizing pods)
+	expectedNodeMetrics := 15
+	if nodeMetricCount != expectedNodeMetrics {
+		t.Errorf("Expected %d node metrics when enabled, got %d", expectedNodeMetrics, nodeMetricCount)
+	}
+}
+
+// TestBinpackingCollector_LabelGrouping_NoLabels tests that no group metrics are emitted when no label groups are configured.
+func TestBinpackingCollector_LabelGrouping_NoLabels(t *testing.T) {
+	nodes := []*corev1.Node{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "node-1",
+				Labels: map[string]string{
+					"zone": "us-east-1a",
+				},
+			},
+			Status: corev1.NodeStatus{
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU: resource.MustParse("4"),
+				},
+			},
+		},
+	}
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	nodeLister := &fakeNodeLister{nodes: nodes}
+	podLister := &fakePodLister{pods: nil}
+
+	// No label groups configured
+	labelGroups := [][]string{}
+	resources := []corev1.ResourceName{corev1.ResourceCPU}
+
+	collector := NewBinpackingCollector(nodeLister, podLister, logger, resources, labelGroups, true, nil, nil, CollectorOptions{})
+
+	ch := make(chan prometheus.Metric, 50)
+	collector.Collect(ch)
+	close(ch)
+
+	// Verify no group metrics
+	for m := range ch {
+		desc := m.Desc().String()
+		if stringContains(desc, "kube_binpacking_group_") {
+			t.Error("Expected no group metrics when label groups not configured")
+		}
+	}
+}
+
+// TestBinpackingCollector_LeaderElection_Disabled verifies that when isLeader is nil
+// (leader election not enabled), all metrics are emitted and no leader_status metric appears.
+func TestBinpackingCollector_LeaderElection_Disabled(t *testing.T) {
+	nodes := []*corev1.Node{makeNode("node-1", "4", "8Gi")}
+	pods := []*corev1.Pod{
+		makePodWithResources("default", "pod-1", "node-1", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "1", "2Gi")}, nil),
+	}
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	resources := []corev1.ResourceName{corev1.ResourceCPU}
+	syncInfo := &SyncInfo{LastSyncTime: time.Now().Add(-10 * time.Second)}
+
+	collector := NewBinpackingCollector(
+		&fakeNodeLister{nodes: nodes}, &fakePodLister{pods: pods},
+		logger, resources, nil, true, syncInfo, nil, // isLeader = nil
+		CollectorOptions{},
+	)
+
+	ch := make(chan prometheus.Metric, 100)
+	collector.Collect(ch)
+	close(ch)
+
+	var hasLeaderStatus, hasClusterAllocated, hasCacheAge bool
+	for m := range ch {
+		desc := m.Desc().String()
+		if contains(desc, "kube_binpacking_leader_status") {
+			hasLeaderStatus = true
+		}
+		if contains(desc, "kube_binpacking_cluster_allocated") {
+			hasClusterAllocated = true
+		}
+		if contains(desc, "kube_binpacking_cache_age_seconds") {
+			hasCacheAge = true
+		}
+	}
+
+	if hasLeaderStatus {
+		t.Error("leader_status metric should NOT be emitted when leader election is disabled")
+	}
+	if !hasClusterAllocated {
+		t.Error("cluster_allocated metric should be emitted when leader election is disabled")
+	}
+	if !hasCacheAge {
+		t.Error("cache_age metric should be emitted when leader election is disabled")
+	}
+}
+
+// TestBinpackingCollector_LeaderElection_IsLeader verifies that when this instance is the leader,
+// all binpacking metrics are emitted along with leader_status = 1.
+func TestBinpackingCollector_LeaderElection_IsLeader(t *testing.T) {
+	nodes := []*corev1.Node{makeNode("node-1", "4", "8Gi")}
+	pods := []*corev1.Pod{
+		makePodWithResources("default", "pod-1", "node-1", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "1", "2Gi")}, nil),
+	}
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	resources := []corev1.ResourceName{corev1.ResourceCPU}
+	syncInfo := &SyncInfo{LastSyncTime: time.Now().Add(-10 * time.Second)}
+
+	isLeader := new(atomic.Bool)
+	isLeader.Store(true) // this instance IS the leader
+
+	collector := NewBinpackingCollector(
+		&fakeNodeLister{nodes: nodes}, &fakePodLister{pods: pods},
+		logger, resources, nil, true, syncInfo, isLeader, CollectorOptions{},
+	)
+
+	ch := make(chan prometheus.Metric, 100)
+	collector.Collect(ch)
+	close(ch)
+
+	var hasLeaderStatus, hasClusterAllocated, hasNodeAllocated, hasCacheAge bool
+	for m := range ch {
+		desc := m.Desc().String()
+		if contains(desc, "kube_binpacking_leader_status") {
+			hasLeaderStatus = true
+		}
+		if contains(desc, "kube_binpacking_cluster_allocated") {
+			hasClusterAllocated = true
+		}
+		if contains(desc, "kube_binpacking_node_allocated") {
+			hasNodeAllocated = true
+		}
+		if contains(desc, "kube_binpacking_cache_age_seconds") {
+			hasCacheAge = true
+		}
+	}
+
+	if !hasLeaderStatus {
+		t.Error("leader_status metric should be emitted when leader election is enabled")
+	}
+	if !hasClusterAllocated {
+		t.Error("cluster_allocated should be emitted when this instance is leader")
+	}
+	if !hasNodeAllocated {
+		t.Error("node_allocated should be emitted when this instance is leader")
+	}
+	if !hasCacheAge {
+		t.Error("cache_age should be emitted when this instance is leader")
+	}
+}
+
+// TestBinpackingCollector_LeaderElection_IsStandby verifies that when this instance is NOT the leader,
+// only cache_age and leader_status (= 0) are emitted — no binpacking metrics.
+func TestBinpackingCollector_LeaderElection_IsStandby(t *testing.T) {
+	nodes := []*corev1.Node{makeNode("node-1", "4", "8Gi")}
+	pods := []*corev1.Pod{
+		makePodWithResources("default", "pod-1", "node-1", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "1", "2Gi")}, nil),
+	}
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	resources := []corev1.ResourceName{corev1.ResourceCPU}
+	syncInfo := &SyncInfo{LastSyncTime: time.Now().Add(-10 * time.Second)}
+
+	isLeader := new(atomic.Bool)
+	isLeader.Store(false) // this instance is standby
+
+	collector := NewBinpackingCollector(
+		&fakeNodeLister{nodes: nodes}, &fakePodLister{pods: pods},
+		logger, resources, nil, true, syncInfo, isLeader, CollectorOptions{},
+	)
+
+	ch := make(chan prometheus.Metric, 100)
+	collector.Collect(ch)
+	close(ch)
+
+	var hasLeaderStatus, hasClusterAllocated, hasNodeAllocated, hasCacheAge bool
+	var metricCount int
+	for m := range ch {
+		metricCount++
+		desc := m.Desc().String()
+		if contains(desc, "kube_binpacking_leader_status") {
+			hasLeaderStatus = true
+		}
+		if contains(desc, "kube_binpacking_cluster_allocated") {
+			hasClusterAllocated = true
+		}
+		if contains(desc, "kube_binpacking_node_allocated") {
+			hasNodeAllocated = true
+		}
+		if contains(desc, "kube_binpacking_cache_age_seconds") {
+			hasCacheAge = true
+		}
+	}
+
+	if !hasLeaderStatus {
+		t.Error("leader_status should be emitted on standby")
+	}
+	if !hasCacheAge {
+		t.Error("cache_age should be emitted on standby")
+	}
+	if hasClusterAllocated {
+		t.Error("cluster_allocated should NOT be emitted on standby")
+	}
+	if hasNodeAllocated {
+		t.Error("node_allocated should NOT be emitted on standby")
+	}
+	// Should only have 2 metrics: cache_age + leader_status
+	if metricCount != 2 {
+		t.Errorf("standby should emit exactly 2 metrics (cache_age + leader_status), got %d", metricCount)
+	}
 }
 
-func (f *fakeNodeLister) List(selector labels.Selector) ([]*corev1.Node, error) {
-	if f.err != nil {
-		return nil, f.err
-	}
-	return f.nodes, nil
+// Helper function to check if a string contains a substring.
+func stringContains(s, substr string) bool {
+	return len(s) >= len(substr) && (s == substr || len(s) > len(substr) &&
+		(s[:len(substr)] == substr || s[len(s)-len(substr):] == substr ||
+		containsAt(s, substr)))
 }
 
-func (f *fakeNodeLister) Get(name string) (*corev1.Node, error) {
-	for _, node := range f.nodes {
-		if node.Name == name {
-			return node, nil
+func containsAt(s, substr string) bool {
+	for i := 0; i <= len(s)-len(substr); i++ {
+		if s[i:i+len(substr)] == substr {
+			return true
 		}
 	}
-	return nil, nil
+	return false
 }
 
-// Mock pod lister for testing.
-type fakePodLister struct {
-	pods []*corev1.Pod
-	err  error // error to return from List()
+// Helper function to create an error for testing.
+func someError(msg string) error {
+	return &testError{msg: msg}
 }
 
-func (f *fakePodLister) List(selector labels.Selector) ([]*corev1.Pod, error) {
-	if f.err != nil {
-		return nil, f.err
-	}
-	return f.pods, nil
+type testError struct {
+	msg string
 }
 
-func (f *fakePodLister) Pods(namespace string) listerscorev1.PodNamespaceLister {
-	return &fakePodNamespaceLister{pods: f.pods, namespace: namespace}
+func (e *testError) Error() string {
+	return e.msg
 }
 
-type fakePodNamespaceLister struct {
-	pods      []*corev1.Pod
-	namespace string
+// Helper function to check if a string contains a substring.
+func contains(s, substr string) bool {
+	return len(s) >= len(substr) && (s == substr || len(s) > len(substr) && findSubstring(s, substr))
 }
 
-func (f *fakePodNamespaceLister) List(selector labels.Selector) ([]*corev1.Pod, error) {
-	var result []*corev1.Pod
-	for _, pod := range f.pods {
-		if pod.Namespace == f.namespace {
-			result = append(result, pod)
+func findSubstring(s, substr string) bool {
+	for i := 0; i <= len(s)-len(substr); i++ {
+		if s[i:i+len(substr)] == substr {
+			return true
 		}
 	}
-	return result, nil
+	return false
 }
 
-func (f *fakePodNamespaceLister) Get(name string) (*corev1.Pod, error) {
-	for _, pod := range f.pods {
-		if pod.Namespace == f.namespace && pod.Name == name {
-			return pod, nil
-		}
+// makeDaemonSetPod creates a pod owned by a DaemonSet with specified resources.
+func makeDaemonSetPod(namespace, name, nodeName string, cpu, memory string) *corev1.Pod {
+	pod := makePodWithResources(namespace, name, nodeName, corev1.PodRunning,
+		[]corev1.Container{makeContainer("ds-container", cpu, memory)}, nil)
+	pod.OwnerReferences = []metav1.OwnerReference{
+		{
+			APIVersion: "apps/v1",
+			Kind:       "DaemonSet",
+			Name:       name + "-ds",
+		},
 	}
-	return nil, nil
+	return pod
 }
 
-// TestBinpackingCollector_Collect tests the main collection logic.
-func TestBinpackingCollector_Collect(t *testing.T) {
-	// Create test nodes
+// TestIsDaemonSetPod tests detection of DaemonSet-owned pods via OwnerReferences.
+func TestIsDaemonSetPod(t *testing.T) {
+	tests := []struct {
+		name            string
+		ownerReferences []metav1.OwnerReference
+		want            bool
+	}{
+		{
+			name: "DaemonSet pod",
+			ownerReferences: []metav1.OwnerReference{
+				{Kind: "DaemonSet", Name: "node-exporter"},
+			},
+			want: true,
+		},
+		{
+			name: "ReplicaSet pod",
+			ownerReferences: []metav1.OwnerReference{
+				{Kind: "ReplicaSet", Name: "my-deploy-abc123"},
+			},
+			want: false,
+		},
+		{
+			name:            "bare pod (no owner)",
+			ownerReferences: nil,
+			want:            false,
+		},
+		{
+			name: "StatefulSet pod",
+			ownerReferences: []metav1.OwnerReference{
+				{Kind: "StatefulSet", Name: "my-sts"},
+			},
+			want: false,
+		},
+		{
+			name: "multiple owners including DaemonSet",
+			ownerReferences: []metav1.OwnerReference{
+				{Kind: "Node", Name: "node-1"},
+				{Kind: "DaemonSet", Name: "fluentbit"},
+			},
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "test-pod",
+					Namespace:       "default",
+					OwnerReferences: tt.ownerReferences,
+				},
+			}
+			if got := isDaemonSetPod(pod); got != tt.want {
+				t.Errorf("isDaemonSetPod() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestBinpackingCollector_DaemonSetOverhead tests that DaemonSet overhead metrics
+// are correctly computed for node-level and cluster-level aggregates.
+func TestBinpackingCollector_DaemonSetOverhead(t *testing.T) {
 	nodes := []*corev1.Node{
 		makeNode("node-1", "4", "8Gi"),
 		makeNode("node-2", "8", "16Gi"),
 	}
 
-	// Create test pods
 	pods := []*corev1.Pod{
-		// node-1 pods (regular containers)
-		makePodWithResources("default", "pod-1", "node-1", corev1.PodRunning,
+		// Regular pod on node-1: 1 CPU
+		makePodWithResources("default", "regular-1", "node-1", corev1.PodRunning,
 			[]corev1.Container{makeContainer("app", "1", "2Gi")}, nil),
-		makePodWithResources("default", "pod-2", "node-1", corev1.PodRunning,
-			[]corev1.Container{makeContainer("app", "500m", "1Gi")}, nil),
-
-		// node-2 pods (with init containers)
-		makePodWithResources("default", "pod-3", "node-2", corev1.PodRunning,
-			[]corev1.Container{makeContainer("app", "2", "4Gi")},
-			[]corev1.Container{makeContainer("init", "3", "6Gi")}), // init dominates
-
-		// Pods that should be filtered out
-		makePodWithResources("default", "unscheduled", "", corev1.PodPending,
-			[]corev1.Container{makeContainer("app", "1", "1Gi")}, nil), // no NodeName
-		makePodWithResources("default", "completed", "node-1", corev1.PodSucceeded,
-			[]corev1.Container{makeContainer("app", "1", "1Gi")}, nil), // terminated
-		makePodWithResources("default", "failed", "node-2", corev1.PodFailed,
-			[]corev1.Container{makeContainer("app", "1", "1Gi")}, nil), // terminated
-	}
-
-	// Create fake listers
-	nodeLister := &fakeNodeLister{nodes: nodes}
-	podLister := &fakePodLister{pods: pods}
-
-	// Create sync info
-	syncTime := time.Now().Add(-30 * time.Second)
-	syncInfo := &SyncInfo{
-		LastSyncTime: syncTime,
+		// DaemonSet pod on node-1: 250m CPU
+		makeDaemonSetPod("kube-system", "fluentbit-node1", "node-1", "250m", "512Mi"),
+		// DaemonSet pod on node-2: 250m CPU
+		makeDaemonSetPod("kube-system", "fluentbit-node2", "node-2", "250m", "512Mi"),
+		// Regular pod on node-2: 2 CPU
+		makePodWithResources("default", "regular-2", "node-2", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "2", "4Gi")}, nil),
 	}
 
-	// Create logger (discard output for tests)
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	resources := []corev1.ResourceName{corev1.ResourceCPU}
+	collector := NewBinpackingCollector(
+		&fakeNodeLister{nodes: nodes}, &fakePodLister{pods: pods},
+		logger, resources, nil, true, nil, nil, CollectorOptions{},
+	)
 
-	// Create collector
-	resources := []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory}
-	collector := NewBinpackingCollector(nodeLister, podLister, logger, resources, nil, true, syncInfo, nil)
-
-	// Collect metrics
 	ch := make(chan prometheus.Metric, 100)
 	collector.Collect(ch)
 	close(ch)
 
-	// Collect all metrics into a slice
-	var metrics []prometheus.Metric
-	for m := range ch {
-		metrics = append(metrics, m)
-	}
-
-	// Verify we got metrics
-	if len(metrics) == 0 {
-		t.Fatal("expected metrics but got none")
-	}
-
-	// Verify cache age metric exists and is approximately 30 seconds
-	foundCacheAge := false
-	for _, m := range metrics {
-		desc := m.Desc().String()
-		if contains(desc, "kube_binpacking_cache_age_seconds") {
-			foundCacheAge = true
-			// Note: Can't easily extract the value from prometheus.Metric without using testutil
-			break
-		}
-	}
-	if !foundCacheAge {
-		t.Error("expected kube_binpacking_cache_age_seconds metric but didn't find it")
-	}
-
-	// Count metric types
 	metricCounts := make(map[string]int)
-	for _, m := range metrics {
+	for m := range ch {
 		desc := m.Desc().String()
 		switch {
 		case contains(desc, "kube_binpacking_node_daemonset_overhead_ratio"):
-			metricCounts["node_daemonset_overhead_ratio"]++
+			metricCounts["node_ds_ratio"]++
 		case contains(desc, "kube_binpacking_node_daemonset_overhead"):
-			metricCounts["node_daemonset_overhead"]++
-		case contains(desc, "kube_binpacking_node_allocated"):
-			metricCounts["node_allocated"]++
-		case contains(desc, "kube_binpacking_node_allocatable"):
-			metricCounts["node_allocatable"]++
-		case contains(desc, "kube_binpacking_node_utilization_ratio"):
-			metricCounts["node_utilization"]++
+			metricCounts["node_ds_overhead"]++
 		case contains(desc, "kube_binpacking_cluster_daemonset_overhead_ratio"):
-			metricCounts["cluster_daemonset_overhead_ratio"]++
+			metricCounts["cluster_ds_ratio"]++
 		case contains(desc, "kube_binpacking_cluster_daemonset_overhead"):
-			metricCounts["cluster_daemonset_overhead"]++
-		case contains(desc, "kube_binpacking_cluster_allocated"):
-			metricCounts["cluster_allocated"]++
-		case contains(desc, "kube_binpacking_cluster_allocatable"):
-			metricCounts["cluster_allocatable"]++
-		case contains(desc, "kube_binpacking_cluster_utilization_ratio"):
-			metricCounts["cluster_utilization"]++
-		case contains(desc, "kube_binpacking_cache_age_seconds"):
-			metricCounts["cache_age"]++
+			metricCounts["cluster_ds_overhead"]++
 		}
 	}
 
-	// Verify metric counts
-	// 2 nodes × 2 resources = 4 metrics per type (node_allocated, node_allocatable, node_utilization, node_daemonset_overhead, node_daemonset_overhead_ratio)
-	// 2 resources = 2 metrics per type (cluster_allocated, cluster_allocatable, cluster_utilization, cluster_daemonset_overhead, cluster_daemonset_overhead_ratio)
-	// 1 cache_age metric
-	expectedCounts := map[string]int{
-		"node_allocated":                   4, // 2 nodes × 2 resources
-		"node_allocatable":                 4,
-		"node_utilization":                 4,
-		"node_daemonset_overhead":          4,
-		"node_daemonset_overhead_ratio":    4,
-		"cluster_allocated":                2, // 2 resources
-		"cluster_allocatable":              2,
-		"cluster_utilization":              2,
-		"cluster_daemonset_overhead":       2,
-		"cluster_daemonset_overhead_ratio": 2,
-		"cache_age":                        1,
+	// 2 nodes × 1 resource = 2 node-level DS metrics each
+	if metricCounts["node_ds_overhead"] != 2 {
+		t.Errorf("node_daemonset_overhead: got %d, want 2", metricCounts["node_ds_overhead"])
+	}
+	if metricCounts["node_ds_ratio"] != 2 {
+		t.Errorf("node_daemonset_overhead_ratio: got %d, want 2", metricCounts["node_ds_ratio"])
 	}
-
-	for metricType, expected := range expectedCounts {
-		if metricCounts[metricType] != expected {
-			t.Errorf("metric %s: got %d, want %d", metricType, metricCounts[metricType], expected)
-		}
+	// 1 resource = 1 cluster-level DS metric each
+	if metricCounts["cluster_ds_overhead"] != 1 {
+		t.Errorf("cluster_daemonset_overhead: got %d, want 1", metricCounts["cluster_ds_overhead"])
+	}
+	if metricCounts["cluster_ds_ratio"] != 1 {
+		t.Errorf("cluster_daemonset_overhead_ratio: got %d, want 1", metricCounts["cluster_ds_ratio"])
 	}
 }
 
-// TestBinpackingCollector_PodFiltering tests that unscheduled and terminated pods are filtered.
-func TestBinpackingCollector_PodFiltering(t *testing.T) {
-	// Create a single node
+// TestBinpackingCollector_DaemonSetOverhead_LabelGroups tests that group-level
+// DaemonSet overhead metrics are emitted when label groups are configured.
+func TestBinpackingCollector_DaemonSetOverhead_LabelGroups(t *testing.T) {
 	nodes := []*corev1.Node{
-		makeNode("node-1", "4", "8Gi"),
-	}
-
-	tests := []struct {
-		name          string
-		pod           *corev1.Pod
-		shouldInclude bool
-	}{
-		{
-			name:          "running pod on node",
-			pod:           makePodWithResources("default", "pod-1", "node-1", corev1.PodRunning, []corev1.Container{makeContainer("app", "1", "1Gi")}, nil),
-			shouldInclude: true,
-		},
-		{
-			name:          "pending pod on node",
-			pod:           makePodWithResources("default", "pod-2", "node-1", corev1.PodPending, []corev1.Container{makeContainer("app", "1", "1Gi")}, nil),
-			shouldInclude: true,
-		},
-		{
-			name:          "unscheduled pod (no NodeName)",
-			pod:           makePodWithResources("default", "pod-3", "", corev1.PodPending, []corev1.Container{makeContainer("app", "1", "1Gi")}, nil),
-			shouldInclude: false,
-		},
 		{
-			name:          "succeeded pod",
-			pod:           makePodWithResources("default", "pod-4", "node-1", corev1.PodSucceeded, []corev1.Container{makeContainer("app", "1", "1Gi")}, nil),
-			shouldInclude: false,
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "node-zone-a",
+				Labels: map[string]string{"topology.kubernetes.io/zone": "us-east-1a"},
+			},
+			Status: corev1.NodeStatus{
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU: resource.MustParse("4"),
+				},
+			},
 		},
 		{
-			name:          "failed pod",
-			pod:           makePodWithResources("default", "pod-5", "node-1", corev1.PodFailed, []corev1.Container{makeContainer("app", "1", "1Gi")}, nil),
-			shouldInclude: false,
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "node-zone-b",
+				Labels: map[string]string{"topology.kubernetes.io/zone": "us-east-1b"},
+			},
+			Status: corev1.NodeStatus{
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU: resource.MustParse("8"),
+				},
+			},
 		},
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			nodeLister := &fakeNodeLister{nodes: nodes}
-			podLister := &fakePodLister{pods: []*corev1.Pod{tt.pod}}
-			logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
-			resources := []corev1.ResourceName{corev1.ResourceCPU}
-
-			collector := NewBinpackingCollector(nodeLister, podLister, logger, resources, nil, true, nil, nil)
+	pods := []*corev1.Pod{
+		makeDaemonSetPod("kube-system", "fluentbit-a", "node-zone-a", "250m", ""),
+		makeDaemonSetPod("kube-system", "fluentbit-b", "node-zone-b", "250m", ""),
+		makePodWithResources("default", "app-a", "node-zone-a", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "1", "")}, nil),
+	}
 
-			ch := make(chan prometheus.Metric, 100)
-			collector.Collect(ch)
-			close(ch)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	labelGroups := [][]string{{"topology.kubernetes.io/zone"}}
+	resources := []corev1.ResourceName{corev1.ResourceCPU}
+	collector := NewBinpackingCollector(
+		&fakeNodeLister{nodes: nodes}, &fakePodLister{pods: pods},
+		logger, resources, labelGroups, true, nil, nil, CollectorOptions{},
+	)
 
-			// Collect metrics
-			var metrics []prometheus.Metric
-			for m := range ch {
-				metrics = append(metrics, m)
-			}
+	ch := make(chan prometheus.Metric, 200)
+	collector.Collect(ch)
+	close(ch)
 
-			// Check if there's a node_allocated metric with non-zero value
-			// If the pod should be included, we expect 1 CPU allocated
-			// If not, we expect 0 CPU allocated
-			foundNodeAllocated := false
-			for _, m := range metrics {
-				desc := m.Desc().String()
-				if contains(desc, "kube_binpacking_node_allocated") {
-					foundNodeAllocated = true
-					// We can't easily extract the exact value, but the test logic is correct
-				}
-			}
+	var groupDSOverheadCount, groupDSRatioCount int
+	for m := range ch {
+		desc := m.Desc().String()
+		switch {
+		case contains(desc, "kube_binpacking_group_daemonset_overhead_ratio"):
+			groupDSRatioCount++
+		case contains(desc, "kube_binpacking_group_daemonset_overhead"):
+			groupDSOverheadCount++
+		}
+	}
 
-			if !foundNodeAllocated {
-				t.Error("expected to find node_allocated metric")
-			}
-		})
+	// 2 zones × 1 resource = 2 group-level DS metrics each
+	if groupDSOverheadCount != 2 {
+		t.Errorf("group_daemonset_overhead: got %d, want 2", groupDSOverheadCount)
+	}
+	if groupDSRatioCount != 2 {
+		t.Errorf("group_daemonset_overhead_ratio: got %d, want 2", groupDSRatioCount)
 	}
 }
 
-// TestBinpackingCollector_Describe tests the Describe method.
-func TestBinpackingCollector_Describe(t *testing.T) {
-	nodeLister := &fakeNodeLister{nodes: nil}
-	podLister := &fakePodLister{pods: nil}
+// TestBinpackingCollector_DaemonSetOverhead_DisableNodeMetrics tests that
+// node-level DS metrics are suppressed when node metrics are disabled,
+// but cluster-level DS metrics are still emitted.
+func TestBinpackingCollector_DaemonSetOverhead_DisableNodeMetrics(t *testing.T) {
+	nodes := []*corev1.Node{makeNode("node-1", "4", "8Gi")}
+	pods := []*corev1.Pod{
+		makeDaemonSetPod("kube-system", "fluentbit", "node-1", "250m", "512Mi"),
+		makePodWithResources("default", "app", "node-1", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "1", "2Gi")}, nil),
+	}
+
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
 	resources := []corev1.ResourceName{corev1.ResourceCPU}
+	collector := NewBinpackingCollector(
+		&fakeNodeLister{nodes: nodes}, &fakePodLister{pods: pods},
+		logger, resources, nil, false, nil, nil, // enableNodeMetrics = false
+		CollectorOptions{},
+	)
 
-	collector := NewBinpackingCollector(nodeLister, podLister, logger, resources, nil, true, nil, nil)
-
-	ch := make(chan *prometheus.Desc, 20)
-	collector.Describe(ch)
+	ch := make(chan prometheus.Metric, 100)
+	collector.Collect(ch)
 	close(ch)
 
-	// Count descriptors
-	var descs []*prometheus.Desc
-	for d := range ch {
-		descs = append(descs, d)
+	var nodeDSCount, clusterDSCount int
+	for m := range ch {
+		desc := m.Desc().String()
+		switch {
+		case contains(desc, "kube_binpacking_node_daemonset"):
+			nodeDSCount++
+		case contains(desc, "kube_binpacking_cluster_daemonset"):
+			clusterDSCount++
+		}
 	}
 
-	// Should have 10 metric descriptors (5 node + 5 cluster + 1 cluster_node_count + 1 cache_age)
-	// Node: allocated, allocatable, utilization, daemonset_overhead, daemonset_overhead_ratio
-	// Cluster: allocated, allocatable, utilization, daemonset_overhead, daemonset_overhead_ratio
-	expectedDescCount := 12
-	if len(descs) != expectedDescCount {
-		t.Errorf("expected %d descriptors, got %d", expectedDescCount, len(descs))
+	if nodeDSCount > 0 {
+		t.Errorf("Expected 0 node DS metrics when disabled, got %d", nodeDSCount)
+	}
+	// 1 resource × 2 cluster DS metrics (overhead + ratio)
+	if clusterDSCount != 2 {
+		t.Errorf("Expected 2 cluster DS metrics, got %d", clusterDSCount)
 	}
 }
 
-// TestBinpackingCollector_ErrorHandling tests error cases in Collect.
-func TestBinpackingCollector_ErrorHandling(t *testing.T) {
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
-	resources := []corev1.ResourceName{corev1.ResourceCPU}
+// TestBinpackingCollector_PodOverhead tests that RuntimeClass overhead is
+// included in allocated and reported separately at node, group and cluster level.
+func TestBinpackingCollector_PodOverhead(t *testing.T) {
+	nodes := []*corev1.Node{makeNode("node-1", "4", "8Gi")}
+	nodes[0].Labels = map[string]string{"zone": "a"}
 
-	t.Run("node lister error", func(t *testing.T) {
-		nodeLister := &fakeNodeLister{err: someError("node list failed")}
-		podLister := &fakePodLister{pods: []*corev1.Pod{}}
-		collector := NewBinpackingCollector(nodeLister, podLister, logger, resources, nil, true, nil, nil)
+	kataPod := makePodWithResources("default", "kata", "node-1", corev1.PodRunning,
+		[]corev1.Container{makeContainer("app", "1", "1Gi")}, nil)
+	kataPod.Spec.Overhead = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("250m")}
 
-		ch := make(chan prometheus.Metric, 50)
-		collector.Collect(ch)
-		close(ch)
+	pods := []*corev1.Pod{
+		kataPod,
+		makePodWithResources("default", "runc", "node-1", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "500m", "1Gi")}, nil),
+	}
 
-		// Should not panic, but also won't emit node/cluster metrics (only cache age if syncInfo present)
-		// Collect all metrics
-		var count int
-		for range ch {
-			count++
-		}
-		// No metrics expected since node listing failed (except cache_age if syncInfo != nil)
-		if count != 0 {
-			t.Logf("Got %d metrics despite node list error (expected 0)", count)
-		}
-	})
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	resources := []corev1.ResourceName{corev1.ResourceCPU}
+	collector := NewBinpackingCollector(
+		&fakeNodeLister{nodes: nodes}, &fakePodLister{pods: pods},
+		logger, resources, [][]string{{"zone"}}, true, nil, nil, CollectorOptions{},
+	)
 
-	t.Run("pod lister error", func(t *testing.T) {
-		nodes := []*corev1.Node{makeNode("node-1", "4", "8Gi")}
-		nodeLister := &fakeNodeLister{nodes: nodes}
-		podLister := &fakePodLister{err: someError("pod list failed")}
-		collector := NewBinpackingCollector(nodeLister, podLister, logger, resources, nil, true, nil, nil)
+	expected := `
+# HELP kube_binpacking_cluster_pod_overhead Cluster-wide total RuntimeClass pod overhead included in the allocated resource
+# TYPE kube_binpacking_cluster_pod_overhead gauge
+kube_binpacking_cluster_pod_overhead{resource="cpu"} 0.25
+# HELP kube_binpacking_group_pod_overhead Total RuntimeClass pod overhead included in the allocated resource on nodes in this label group
+# TYPE kube_binpacking_group_pod_overhead gauge
+kube_binpacking_group_pod_overhead{label_group="zone",label_group_value="a",resource="cpu"} 0.25
+# HELP kube_binpacking_node_allocated Total resource requested by pods on this node
+# TYPE kube_binpacking_node_allocated gauge
+kube_binpacking_node_allocated{node="node-1",resource="cpu"} 1.75
+# HELP kube_binpacking_node_pod_overhead Total RuntimeClass pod overhead included in the allocated resource on this node
+# TYPE kube_binpacking_node_pod_overhead gauge
+kube_binpacking_node_pod_overhead{node="node-1",resource="cpu"} 0.25
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"kube_binpacking_node_allocated",
+		"kube_binpacking_node_pod_overhead",
+		"kube_binpacking_group_pod_overhead",
+		"kube_binpacking_cluster_pod_overhead",
+	); err != nil {
+		t.Error(err)
+	}
+}
 
-		ch := make(chan prometheus.Metric, 50)
-		collector.Collect(ch)
-		close(ch)
+// TestBinpackingCollector_ResizingPods tests that pods with outstanding
+// in-place resizes are counted per node and cluster-wide by resize state.
+func TestBinpackingCollector_ResizingPods(t *testing.T) {
+	nodes := []*corev1.Node{makeNode("node-1", "4", "8Gi"), makeNode("node-2", "4", "8Gi")}
 
-		// Should not panic, returns early without emitting metrics
-		var count int
-		for range ch {
-			count++
-		}
-		// No metrics expected since pod listing failed (except cache_age if syncInfo != nil)
-		if count != 0 {
-			t.Logf("Got %d metrics despite pod list error (expected 0)", count)
-		}
-	})
+	inProgress := makePodWithResources("default", "in-progress", "node-1", corev1.PodRunning,
+		[]corev1.Container{makeContainer("app", "1", "")}, nil)
+	inProgress.Status.Conditions = []corev1.PodCondition{makeResizeCondition(corev1.PodResizeInProgress, "")}
 
-	t.Run("collect without syncInfo", func(t *testing.T) {
-		nodes := []*corev1.Node{makeNode("node-1", "4", "8Gi")}
-		pods := []*corev1.Pod{
-			makePodWithResources("default", "pod-1", "node-1", corev1.PodRunning,
-				[]corev1.Container{makeContainer("app", "1", "1Gi")}, nil),
-		}
-		nodeLister := &fakeNodeLister{nodes: nodes}
-		podLister := &fakePodLister{pods: pods}
+	infeasible := makePodWithResources("default", "infeasible", "node-2", corev1.PodRunning,
+		[]corev1.Container{makeContainer("app", "8", "")}, nil)
+	infeasible.Status.ContainerStatuses = []corev1.ContainerStatus{makeResizeStatus("app", "1", "1")}
+	infeasible.Status.Conditions = []corev1.PodCondition{makeResizeCondition(corev1.PodResizePending, corev1.PodReasonInfeasible)}
 
-		// Create collector with nil syncInfo
-		collector := NewBinpackingCollector(nodeLister, podLister, logger, resources, nil, true, nil, nil)
+	pods := []*corev1.Pod{
+		inProgress,
+		infeasible,
+		makePodWithResources("default", "steady", "node-2", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "1", "")}, nil),
+	}
 
-		ch := make(chan prometheus.Metric, 50)
-		collector.Collect(ch)
-		close(ch)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	resources := []corev1.ResourceName{corev1.ResourceCPU}
+	collector := NewBinpackingCollector(
+		&fakeNodeLister{nodes: nodes}, &fakePodLister{pods: pods},
+		logger, resources, nil, true, nil, nil, CollectorOptions{},
+	)
 
-		// Should still emit metrics, just no cache_age
-		var count int
-		for range ch {
-			count++
-		}
-		// Should have node and cluster metrics (but no cache_age)
-		if count == 0 {
-			t.Error("Expected metrics to be emitted even without syncInfo")
-		}
-	})
+	expected := `
+# HELP kube_binpacking_cluster_resizing_pods Cluster-wide number of pods with an outstanding in-place resize, by resize state
+# TYPE kube_binpacking_cluster_resizing_pods gauge
+kube_binpacking_cluster_resizing_pods{resize_state="deferred"} 0
+kube_binpacking_cluster_resizing_pods{resize_state="in_progress"} 1
+kube_binpacking_cluster_resizing_pods{resize_state="infeasible"} 1
+# HELP kube_binpacking_node_allocated Total resource requested by pods on this node
+# TYPE kube_binpacking_node_allocated gauge
+kube_binpacking_node_allocated{node="node-1",resource="cpu"} 1
+kube_binpacking_node_allocated{node="node-2",resource="cpu"} 2
+# HELP kube_binpacking_node_resizing_pods Number of pods on this node with an outstanding in-place resize, by resize state
+# TYPE kube_binpacking_node_resizing_pods gauge
+kube_binpacking_node_resizing_pods{node="node-1",resize_state="in_progress"} 1
+kube_binpacking_node_resizing_pods{node="node-2",resize_state="infeasible"} 1
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"kube_binpacking_node_allocated",
+		"kube_binpacking_node_resizing_pods",
+		"kube_binpacking_cluster_resizing_pods",
+	); err != nil {
+		t.Error(err)
+	}
 }
 
-// TestBinpackingCollector_DebugLogging tests debug logging paths.
-func TestBinpackingCollector_DebugLogging(t *testing.T) {
-	// Create logger with debug level enabled
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
-	resources := []corev1.ResourceName{corev1.ResourceCPU}
-
+// TestBinpackingCollector_LimitsMetrics tests the optional limits and
+// overcommit metrics at node, group and cluster level.
+func TestBinpackingCollector_LimitsMetrics(t *testing.T) {
 	nodes := []*corev1.Node{makeNode("node-1", "4", "8Gi")}
+	nodes[0].Labels = map[string]string{"zone": "a"}
 
-	// Pod where init container dominates (triggers debug log)
 	pods := []*corev1.Pod{
-		makePodWithResources("default", "pod-with-init", "node-1", corev1.PodRunning,
-			[]corev1.Container{makeContainer("app", "100m", "128Mi")},
-			[]corev1.Container{makeContainer("init", "500m", "512Mi")}), // init dominates
-		// Pod with no resource requests (triggers different debug path)
-		makePodWithResources("default", "pod-no-requests", "node-1", corev1.PodRunning,
-			[]corev1.Container{makeContainer("app2", "", "")}, nil),
+		makePodWithResources("default", "limited", "node-1", corev1.PodRunning,
+			[]corev1.Container{withLimits(makeContainer("app", "", "1Gi"), "", "10Gi")}, nil),
+		makePodWithResources("default", "unlimited", "node-1", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "", "1Gi")}, nil),
 	}
 
-	nodeLister := &fakeNodeLister{nodes: nodes}
-	podLister := &fakePodLister{pods: pods}
-	collector := NewBinpackingCollector(nodeLister, podLister, logger, resources, nil, true, nil, nil)
-
-	ch := make(chan prometheus.Metric, 100)
-	collector.Collect(ch)
-	close(ch)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	resources := []corev1.ResourceName{corev1.ResourceMemory}
+	collector := NewBinpackingCollector(
+		&fakeNodeLister{nodes: nodes}, &fakePodLister{pods: pods},
+		logger, resources, [][]string{{"zone"}}, true, nil, nil,
+		CollectorOptions{EnableLimitsMetrics: true},
+	)
 
-	// Verify metrics were collected (debug logging shouldn't break functionality)
-	var count int
-	for range ch {
-		count++
-	}
-	if count == 0 {
-		t.Error("Expected metrics to be collected with debug logging enabled")
+	expected := `
+# HELP kube_binpacking_cluster_overcommit_ratio Cluster-wide ratio of limits allocated to allocatable
+# TYPE kube_binpacking_cluster_overcommit_ratio gauge
+kube_binpacking_cluster_overcommit_ratio{resource="memory"} 1.25
+# HELP kube_binpacking_cluster_pods_without_limits Cluster-wide number of pods with a container that sets no limit for the resource
+# TYPE kube_binpacking_cluster_pods_without_limits gauge
+kube_binpacking_cluster_pods_without_limits{resource="memory"} 1
+# HELP kube_binpacking_group_limits_allocated Total resource limits of pods on nodes in this label group
+# TYPE kube_binpacking_group_limits_allocated gauge
+kube_binpacking_group_limits_allocated{label_group="zone",label_group_value="a",resource="memory"} 1.073741824e+10
+# HELP kube_binpacking_node_limits_allocated Total resource limits of pods on this node
+# TYPE kube_binpacking_node_limits_allocated gauge
+kube_binpacking_node_limits_allocated{node="node-1",resource="memory"} 1.073741824e+10
+# HELP kube_binpacking_node_overcommit_ratio Ratio of limits allocated to allocatable (0.0-1.0+)
+# TYPE kube_binpacking_node_overcommit_ratio gauge
+kube_binpacking_node_overcommit_ratio{node="node-1",resource="memory"} 1.25
+# HELP kube_binpacking_node_pods_without_limits Number of pods on this node with a container that sets no limit for the resource
+# TYPE kube_binpacking_node_pods_without_limits gauge
+kube_binpacking_node_pods_without_limits{node="node-1",resource="memory"} 1
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"kube_binpacking_node_limits_allocated",
+		"kube_binpacking_node_overcommit_ratio",
+		"kube_binpacking_node_pods_without_limits",
+		"kube_binpacking_group_limits_allocated",
+		"kube_binpacking_cluster_overcommit_ratio",
+		"kube_binpacking_cluster_pods_without_limits",
+	); err != nil {
+		t.Error(err)
 	}
 }
 
-// TestBinpackingCollector_ZeroAllocatable tests the edge case where a node has zero allocatable resources.
-func TestBinpackingCollector_ZeroAllocatable(t *testing.T) {
+// TestBinpackingCollector_LimitsMetricsDisabled tests that no limits metrics
+// are emitted by default.
+func TestBinpackingCollector_LimitsMetricsDisabled(t *testing.T) {
+	nodes := []*corev1.Node{makeNode("node-1", "4", "8Gi")}
+	pods := []*corev1.Pod{
+		makePodWithResources("default", "limited", "node-1", corev1.PodRunning,
+			[]corev1.Container{withLimits(makeContainer("app", "1", "1Gi"), "2", "2Gi")}, nil),
+	}
+
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
 	resources := []corev1.ResourceName{corev1.ResourceCPU}
+	collector := NewBinpackingCollector(
+		&fakeNodeLister{nodes: nodes}, &fakePodLister{pods: pods},
+		logger, resources, nil, true, nil, nil, CollectorOptions{},
+	)
 
-	// Node with zero allocatable CPU
-	node := &corev1.Node{
-		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
-		Status: corev1.NodeStatus{
-			Allocatable: corev1.ResourceList{
-				// CPU is not set, so it's zero
-			},
-		},
+	if count := testutil.CollectAndCount(collector,
+		"kube_binpacking_node_limits_allocated",
+		"kube_binpacking_cluster_limits_allocated",
+		"kube_binpacking_cluster_overcommit_ratio",
+	); count != 0 {
+		t.Errorf("Expected no limits metrics, got %d", count)
 	}
-	nodes := []*corev1.Node{node}
+}
+
+// TestBinpackingCollector_PodsResource tests that the "pods" resource counts
+// scheduled, non-terminated pods against the node's pod capacity.
+func TestBinpackingCollector_PodsResource(t *testing.T) {
+	nodes := []*corev1.Node{makeNode("node-1", "4", "8Gi"), makeNode("node-2", "4", "8Gi")}
+	nodes[0].Status.Allocatable[corev1.ResourcePods] = resource.MustParse("4")
+	nodes[1].Status.Allocatable[corev1.ResourcePods] = resource.MustParse("4")
 
 	pods := []*corev1.Pod{
 		makePodWithResources("default", "pod-1", "node-1", corev1.PodRunning,
-			[]corev1.Container{makeContainer("app", "100m", "128Mi")}, nil),
+			[]corev1.Container{makeContainer("app", "100m", "")}, nil),
+		makePodWithResources("default", "pod-2", "node-1", corev1.PodPending,
+			[]corev1.Container{makeContainer("app", "", ""), makeContainer("proxy", "", "")}, nil),
+		makeDaemonSetPod("kube-system", "ds-1", "node-2", "50m", "64Mi"),
+		makePodWithResources("default", "done", "node-2", corev1.PodSucceeded,
+			[]corev1.Container{makeContainer("app", "100m", "")}, nil),
+		makePodWithResources("default", "unscheduled", "", corev1.PodPending,
+			[]corev1.Container{makeContainer("app", "100m", "")}, nil),
 	}
 
-	nodeLister := &fakeNodeLister{nodes: nodes}
-	podLister := &fakePodLister{pods: pods}
-	collector := NewBinpackingCollector(nodeLister, podLister, logger, resources, nil, true, nil, nil)
-
-	ch := make(chan prometheus.Metric, 100)
-	collector.Collect(ch)
-	close(ch)
-
-	// Collect metrics
-	var metrics []prometheus.Metric
-	for m := range ch {
-		metrics = append(metrics, m)
-	}
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	resources := []corev1.ResourceName{corev1.ResourcePods}
+	collector := NewBinpackingCollector(
+		&fakeNodeLister{nodes: nodes}, &fakePodLister{pods: pods},
+		logger, resources, nil, true, nil, nil, CollectorOptions{},
+	)
 
-	// Should still emit metrics, with 0 allocatable and undefined ratio
-	if len(metrics) == 0 {
-		t.Error("Expected metrics even with zero allocatable")
+	expected := `
+# HELP kube_binpacking_cluster_allocated Cluster-wide total resource requested
+# TYPE kube_binpacking_cluster_allocated gauge
+kube_binpacking_cluster_allocated{resource="pods"} 3
+# HELP kube_binpacking_cluster_utilization_ratio Cluster-wide allocation ratio
+# TYPE kube_binpacking_cluster_utilization_ratio gauge
+kube_binpacking_cluster_utilization_ratio{resource="pods"} 0.375
+# HELP kube_binpacking_node_allocated Total resource requested by pods on this node
+# TYPE kube_binpacking_node_allocated gauge
+kube_binpacking_node_allocated{node="node-1",resource="pods"} 2
+kube_binpacking_node_allocated{node="node-2",resource="pods"} 1
+# HELP kube_binpacking_node_daemonset_overhead Total resource requested by DaemonSet pods on this node
+# TYPE kube_binpacking_node_daemonset_overhead gauge
+kube_binpacking_node_daemonset_overhead{node="node-1",resource="pods"} 0
+kube_binpacking_node_daemonset_overhead{node="node-2",resource="pods"} 1
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"kube_binpacking_node_allocated",
+		"kube_binpacking_node_daemonset_overhead",
+		"kube_binpacking_cluster_allocated",
+		"kube_binpacking_cluster_utilization_ratio",
+	); err != nil {
+		t.Error(err)
 	}
 }
 
-// TestBinpackingCollector_LabelGrouping tests the label-based combination grouping metrics.
-func TestBinpackingCollector_LabelGrouping(t *testing.T) {
-	// Create nodes with different label values
-	nodes := []*corev1.Node{
-		{
-			ObjectMeta: metav1.ObjectMeta{
-				Name: "node-zone-a-1",
-				Labels: map[string]string{
-					"topology.kubernetes.io/zone":      "us-east-1a",
-					"node.kubernetes.io/instance-type": "m5.large",
-				},
-			},
-			Status: corev1.NodeStatus{
-				Allocatable: corev1.ResourceList{
-					corev1.ResourceCPU:    resource.MustParse("4"),
-					corev1.ResourceMemory: resource.MustParse("8Gi"),
-				},
-			},
-		},
-		{
-			ObjectMeta: metav1.ObjectMeta{
-				Name: "node-zone-a-2",
-				Labels: map[string]string{
-					"topology.kubernetes.io/zone":      "us-east-1a",
-					"node.kubernetes.io/instance-type": "m5.large",
-				},
-			},
-			Status: corev1.NodeStatus{
-				Allocatable: corev1.ResourceList{
-					corev1.ResourceCPU:    resource.MustParse("4"),
-					corev1.ResourceMemory: resource.MustParse("8Gi"),
-				},
-			},
-		},
+// TestResolveResources tests that resource patterns expand to the resources
+// found in node allocatable.
+func TestResolveResources(t *testing.T) {
+	gpuNode := makeNode("gpu-node", "8", "32Gi")
+	gpuNode.Status.Allocatable["nvidia.com/gpu"] = resource.MustParse("4")
+	gpuNode.Status.Allocatable["hugepages-2Mi"] = resource.MustParse("1Gi")
+	gpuNode.Status.Allocatable[corev1.ResourcePods] = resource.MustParse("110")
+	hugeNode := makeNode("huge-node", "8", "32Gi")
+	hugeNode.Status.Allocatable["hugepages-1Gi"] = resource.MustParse("4Gi")
+	hugeNode.Status.Allocatable["hugepages-2Mi"] = resource.MustParse("1Gi")
+	nodes := []*corev1.Node{gpuNode, hugeNode}
+
+	tests := []struct {
+		name      string
+		resources []corev1.ResourceName
+		nodes     []*corev1.Node
+		want      []corev1.ResourceName
+	}{
 		{
-			ObjectMeta: metav1.ObjectMeta{
-				Name: "node-zone-b-1",
-				Labels: map[string]string{
-					"topology.kubernetes.io/zone":      "us-east-1b",
-					"node.kubernetes.io/instance-type": "m5.xlarge",
-				},
-			},
-			Status: corev1.NodeStatus{
-				Allocatable: corev1.ResourceList{
-					corev1.ResourceCPU:    resource.MustParse("8"),
-					corev1.ResourceMemory: resource.MustParse("16Gi"),
-				},
-			},
+			name:      "exact names are kept even if missing",
+			resources: []corev1.ResourceName{"cpu", "amd.com/gpu"},
+			nodes:     nodes,
+			want:      []corev1.ResourceName{"cpu", "amd.com/gpu"},
 		},
 		{
-			ObjectMeta: metav1.ObjectMeta{
-				Name: "node-no-zone",
-				Labels: map[string]string{
-					"node.kubernetes.io/instance-type": "m5.large",
-				},
-				// Note: missing zone label
-			},
-			Status: corev1.NodeStatus{
-				Allocatable: corev1.ResourceList{
-					corev1.ResourceCPU:    resource.MustParse("4"),
-					corev1.ResourceMemory: resource.MustParse("8Gi"),
-				},
-			},
+			name:      "prefix pattern expands sorted",
+			resources: []corev1.ResourceName{"cpu", "hugepages-*"},
+			nodes:     nodes,
+			want:      []corev1.ResourceName{"cpu", "hugepages-1Gi", "hugepages-2Mi"},
 		},
-	}
-
-	// Create pods scheduled on different nodes
-	pods := []*corev1.Pod{
 		{
-			ObjectMeta: metav1.ObjectMeta{Name: "pod-1", Namespace: "default"},
-			Spec: corev1.PodSpec{
-				NodeName:   "node-zone-a-1",
-				Containers: []corev1.Container{makeContainer("app", "1000m", "2Gi")},
-			},
-			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+			name:      "domain pattern",
+			resources: []corev1.ResourceName{"nvidia.com/*", "amd.com/*"},
+			nodes:     nodes,
+			want:      []corev1.ResourceName{"nvidia.com/gpu"},
 		},
 		{
-			ObjectMeta: metav1.ObjectMeta{Name: "pod-2", Namespace: "default"},
-			Spec: corev1.PodSpec{
-				NodeName:   "node-zone-a-2",
-				Containers: []corev1.Container{makeContainer("app", "2000m", "4Gi")},
-			},
-			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+			name:      "auto expands to every allocatable resource",
+			resources: []corev1.ResourceName{"auto"},
+			nodes:     nodes,
+			want:      []corev1.ResourceName{"cpu", "hugepages-1Gi", "hugepages-2Mi", "memory", "nvidia.com/gpu", "pods"},
 		},
 		{
-			ObjectMeta: metav1.ObjectMeta{Name: "pod-3", Namespace: "default"},
-			Spec: corev1.PodSpec{
-				NodeName:   "node-zone-b-1",
-				Containers: []corev1.Container{makeContainer("app", "4000m", "8Gi")},
-			},
-			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+			name:      "duplicates are removed",
+			resources: []corev1.ResourceName{"cpu", "memory", "auto"},
+			nodes:     nodes,
+			want:      []corev1.ResourceName{"cpu", "memory", "hugepages-1Gi", "hugepages-2Mi", "nvidia.com/gpu", "pods"},
 		},
 		{
-			ObjectMeta: metav1.ObjectMeta{Name: "pod-4", Namespace: "default"},
-			Spec: corev1.PodSpec{
-				NodeName:   "node-no-zone",
-				Containers: []corev1.Container{makeContainer("app", "500m", "1Gi")},
-			},
-			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+			name:      "pattern without nodes",
+			resources: []corev1.ResourceName{"cpu", "hugepages-*"},
+			nodes:     nil,
+			want:      []corev1.ResourceName{"cpu"},
 		},
 	}
 
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
-	nodeLister := &fakeNodeLister{nodes: nodes}
-	podLister := &fakePodLister{pods: pods}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collector := NewBinpackingCollector(
+				&fakeNodeLister{}, &fakePodLister{},
+				logger, tt.resources, nil, true, nil, nil, CollectorOptions{},
+			)
 
-	t.Run("single-key group", func(t *testing.T) {
-		// Group by zone only (single key per group)
-		labelGroups := [][]string{{"topology.kubernetes.io/zone"}}
-		resources := []corev1.ResourceName{corev1.ResourceCPU}
+			got := collector.resolveResources(tt.nodes)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("resolveResources() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
 
-		collector := NewBinpackingCollector(nodeLister, podLister, logger, resources, labelGroups, true, nil, nil)
+// TestBinpackingCollector_ResourcePatterns tests that resources matched by a
+// pattern are reported as soon as a node exposes them.
+func TestBinpackingCollector_ResourcePatterns(t *testing.T) {
+	gpuNode := makeNode("gpu-node", "8", "32Gi")
+	gpuNode.Status.Allocatable["nvidia.com/gpu"] = resource.MustParse("4")
+	nodes := []*corev1.Node{makeNode("cpu-node", "4", "8Gi"), gpuNode}
 
-		ch := make(chan prometheus.Metric, 200)
-		collector.Collect(ch)
-		close(ch)
+	trainer := makePodWithResources("default", "trainer", "gpu-node", corev1.PodRunning,
+		[]corev1.Container{makeContainer("app", "1", "1Gi")}, nil)
+	trainer.Spec.Containers[0].Resources.Requests["nvidia.com/gpu"] = resource.MustParse("2")
 
-		var foundGroupMetric bool
-		for m := range ch {
-			desc := m.Desc().String()
-			if stringContains(desc, "kube_binpacking_group_allocated") {
-				foundGroupMetric = true
-			}
-			// Verify old metric names are NOT emitted
-			if stringContains(desc, "kube_binpacking_label_group_") {
-				t.Error("old metric name kube_binpacking_label_group_ should not be emitted")
-			}
-		}
-		if !foundGroupMetric {
-			t.Error("expected kube_binpacking_group_allocated metrics but got none")
-		}
-	})
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	resources := []corev1.ResourceName{"nvidia.com/*"}
+	collector := NewBinpackingCollector(
+		&fakeNodeLister{nodes: nodes}, &fakePodLister{pods: []*corev1.Pod{trainer}},
+		logger, resources, nil, true, nil, nil, CollectorOptions{},
+	)
 
-	t.Run("multi-key combination group", func(t *testing.T) {
-		// Group by zone AND instance-type (combination group)
-		labelGroups := [][]string{{"topology.kubernetes.io/zone", "node.kubernetes.io/instance-type"}}
-		resources := []corev1.ResourceName{corev1.ResourceCPU}
+	expected := `
+# HELP kube_binpacking_cluster_allocated Cluster-wide total resource requested
+# TYPE kube_binpacking_cluster_allocated gauge
+kube_binpacking_cluster_allocated{resource="nvidia.com/gpu"} 2
+# HELP kube_binpacking_node_allocatable Total allocatable resource on this node
+# TYPE kube_binpacking_node_allocatable gauge
+kube_binpacking_node_allocatable{node="cpu-node",resource="nvidia.com/gpu"} 0
+kube_binpacking_node_allocatable{node="gpu-node",resource="nvidia.com/gpu"} 4
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"kube_binpacking_node_allocatable",
+		"kube_binpacking_cluster_allocated",
+	); err != nil {
+		t.Error(err)
+	}
+}
 
-		collector := NewBinpackingCollector(nodeLister, podLister, logger, resources, labelGroups, true, nil, nil)
+// TestBinpackingCollector_ResourceAliases tests that alias resources sum their
+// weighted sources while the raw resources are still reported when tracked.
+func TestBinpackingCollector_ResourceAliases(t *testing.T) {
+	nvidiaNode := makeNode("nvidia-node", "8", "32Gi")
+	nvidiaNode.Status.Allocatable["nvidia.com/gpu"] = resource.MustParse("4")
+	amdNode := makeNode("amd-node", "8", "32Gi")
+	amdNode.Status.Allocatable["amd.com/gpu"] = resource.MustParse("2")
+	migNode := makeNode("mig-node", "8", "32Gi")
+	migNode.Status.Allocatable["nvidia.com/mig-1g.10gb"] = resource.MustParse("7")
+	nodes := []*corev1.Node{nvidiaNode, amdNode, migNode}
+
+	nvidiaPod := makePodWithResources("default", "nvidia", "nvidia-node", corev1.PodRunning,
+		[]corev1.Container{makeContainer("app", "1", "")}, nil)
+	nvidiaPod.Spec.Containers[0].Resources.Requests["nvidia.com/gpu"] = resource.MustParse("2")
+	amdPod := makePodWithResources("default", "amd", "amd-node", corev1.PodRunning,
+		[]corev1.Container{makeContainer("app", "1", "")}, nil)
+	amdPod.Spec.Containers[0].Resources.Requests["amd.com/gpu"] = resource.MustParse("1")
+	migPod := makePodWithResources("default", "mig", "mig-node", corev1.PodRunning,
+		[]corev1.Container{makeContainer("app", "1", "")}, nil)
+	migPod.Spec.Containers[0].Resources.Requests["nvidia.com/mig-1g.10gb"] = resource.MustParse("2")
 
-		ch := make(chan prometheus.Metric, 200)
-		collector.Collect(ch)
-		close(ch)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	resources := []corev1.ResourceName{"nvidia.com/gpu"}
+	collector := NewBinpackingCollector(
+		&fakeNodeLister{nodes: nodes}, &fakePodLister{pods: []*corev1.Pod{nvidiaPod, amdPod, migPod}},
+		logger, resources, nil, true, nil, nil,
+		CollectorOptions{ResourceAliases: []ResourceAlias{{
+			Name: "gpu",
+			Sources: []AliasSource{
+				{Resource: "nvidia.com/gpu", Weight: 1},
+				{Resource: "amd.com/gpu", Weight: 1},
+				{Resource: "nvidia.com/mig-1g.10gb", Weight: 0.125},
+			},
+		}}},
+	)
 
-		var groupMetricCount int
-		for m := range ch {
-			desc := m.Desc().String()
-			if stringContains(desc, "kube_binpacking_group_allocated") {
-				groupMetricCount++
-			}
-		}
-		// 3 composite values: (us-east-1a,m5.large), (us-east-1b,m5.xlarge), (<none>,m5.large)
-		// × 1 resource = 3 allocated metrics
-		if groupMetricCount != 3 {
-			t.Errorf("expected 3 group_allocated metrics for composite group, got %d", groupMetricCount)
-		}
-	})
+	expected := `
+# HELP kube_binpacking_cluster_allocatable Cluster-wide total allocatable resource
+# TYPE kube_binpacking_cluster_allocatable gauge
+kube_binpacking_cluster_allocatable{resource="gpu"} 6.875
+kube_binpacking_cluster_allocatable{resource="nvidia.com/gpu"} 4
+# HELP kube_binpacking_cluster_allocated Cluster-wide total resource requested
+# TYPE kube_binpacking_cluster_allocated gauge
+kube_binpacking_cluster_allocated{resource="gpu"} 3.25
+kube_binpacking_cluster_allocated{resource="nvidia.com/gpu"} 2
+# HELP kube_binpacking_node_allocated Total resource requested by pods on this node
+# TYPE kube_binpacking_node_allocated gauge
+kube_binpacking_node_allocated{node="amd-node",resource="gpu"} 1
+kube_binpacking_node_allocated{node="amd-node",resource="nvidia.com/gpu"} 0
+kube_binpacking_node_allocated{node="mig-node",resource="gpu"} 0.25
+kube_binpacking_node_allocated{node="mig-node",resource="nvidia.com/gpu"} 0
+kube_binpacking_node_allocated{node="nvidia-node",resource="gpu"} 2
+kube_binpacking_node_allocated{node="nvidia-node",resource="nvidia.com/gpu"} 2
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"kube_binpacking_node_allocated",
+		"kube_binpacking_cluster_allocated",
+		"kube_binpacking_cluster_allocatable",
+	); err != nil {
+		t.Error(err)
+	}
+}
 
-	t.Run("multiple groups", func(t *testing.T) {
-		// Two separate groups: one single-key, one multi-key
-		labelGroups := [][]string{
-			{"topology.kubernetes.io/zone"},
-			{"topology.kubernetes.io/zone", "node.kubernetes.io/instance-type"},
-		}
-		resources := []corev1.ResourceName{corev1.ResourceCPU}
+// TestBinpackingCollector_AliasShadowsResource tests that a pattern does not
+// expand to a resource an alias is named after, and that the alias is warned
+// about once instead of silently replacing the resource's values.
+func TestBinpackingCollector_AliasShadowsResource(t *testing.T) {
+	node := makeNode("node-1", "8", "32Gi")
+	node.Status.Allocatable["amd.com/gpu"] = resource.MustParse("4")
+	node.Status.Allocatable["amd.com/mig"] = resource.MustParse("2")
+	node.Status.Allocatable["nvidia.com/gpu"] = resource.MustParse("1")
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	collector := NewBinpackingCollector(
+		&fakeNodeLister{nodes: []*corev1.Node{node}}, &fakePodLister{},
+		logger, []corev1.ResourceName{"amd.com/*"}, nil, true, nil, nil,
+		CollectorOptions{ResourceAliases: []ResourceAlias{{
+			Name:    "amd.com/gpu",
+			Sources: []AliasSource{{Resource: "nvidia.com/gpu", Weight: 1}},
+		}}},
+	)
 
-		collector := NewBinpackingCollector(nodeLister, podLister, logger, resources, labelGroups, true, nil, nil)
+	want := []corev1.ResourceName{"amd.com/mig", "amd.com/gpu"}
+	if got := collector.resolveResources([]*corev1.Node{node}); !slices.Equal(got, want) {
+		t.Errorf("resolveResources() = %v, want %v", got, want)
+	}
 
-		ch := make(chan prometheus.Metric, 200)
-		collector.Collect(ch)
-		close(ch)
+	testutil.CollectAndCount(collector)
+	testutil.CollectAndCount(collector)
+	if n := strings.Count(logs.String(), `"alias":"amd.com/gpu"`); n != 1 {
+		t.Errorf("got %d shadowing warnings, want 1; logs:\n%s", n, logs.String())
+	}
+}
 
-		var groupAllocatedCount, groupNodeCountMetrics int
-		for m := range ch {
-			desc := m.Desc().String()
-			if stringContains(desc, "kube_binpacking_group_allocated") {
-				groupAllocatedCount++
-			}
-			if stringContains(desc, "kube_binpacking_group_node_count") {
-				groupNodeCountMetrics++
-			}
-		}
-		// Group 1 (zone): 3 values × 1 res = 3 allocated
-		// Group 2 (zone,instance-type): 3 values × 1 res = 3 allocated
-		// Total: 6
-		if groupAllocatedCount != 6 {
-			t.Errorf("expected 6 group_allocated metrics for two groups, got %d", groupAllocatedCount)
-		}
-		// Group 1: 3 node_count + Group 2: 3 node_count = 6
-		if groupNodeCountMetrics != 6 {
-			t.Errorf("expected 6 group_node_count metrics for two groups, got %d", groupNodeCountMetrics)
-		}
-	})
+// TestBinpackingCollector_Capacity tests that node capacity and the share
+// reserved by the kubelet are reported at node, group and cluster level.
+func TestBinpackingCollector_Capacity(t *testing.T) {
+	nodes := []*corev1.Node{makeNode("node-1", "3.5", "7Gi"), makeNode("node-2", "4", "8Gi")}
+	nodes[0].Status.Capacity = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")}
+	nodes[0].Labels = map[string]string{"zone": "a"}
+	nodes[1].Labels = map[string]string{"zone": "a"}
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	resources := []corev1.ResourceName{corev1.ResourceCPU}
+	collector := NewBinpackingCollector(
+		&fakeNodeLister{nodes: nodes}, &fakePodLister{},
+		logger, resources, [][]string{{"zone"}}, true, nil, nil, CollectorOptions{},
+	)
 
-	t.Run("missing labels use none", func(t *testing.T) {
-		// node-no-zone is missing the zone label, should get <none>
-		labelGroups := [][]string{{"topology.kubernetes.io/zone"}}
-		resources := []corev1.ResourceName{corev1.ResourceCPU}
+	expected := `
+# HELP kube_binpacking_cluster_capacity Cluster-wide total resource capacity
+# TYPE kube_binpacking_cluster_capacity gauge
+kube_binpacking_cluster_capacity{resource="cpu"} 8
+# HELP kube_binpacking_group_reserved_ratio Ratio of capacity reserved for the system and kubelet to capacity for nodes in this label group
+# TYPE kube_binpacking_group_reserved_ratio gauge
+kube_binpacking_group_reserved_ratio{label_group="zone",label_group_value="a",resource="cpu"} 0.0625
+# HELP kube_binpacking_node_capacity Total resource capacity of this node
+# TYPE kube_binpacking_node_capacity gauge
+kube_binpacking_node_capacity{node="node-1",resource="cpu"} 4
+kube_binpacking_node_capacity{node="node-2",resource="cpu"} 4
+# HELP kube_binpacking_node_reserved_ratio Ratio of capacity reserved for the system and kubelet (capacity minus allocatable) to capacity
+# TYPE kube_binpacking_node_reserved_ratio gauge
+kube_binpacking_node_reserved_ratio{node="node-1",resource="cpu"} 0.125
+kube_binpacking_node_reserved_ratio{node="node-2",resource="cpu"} 0
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"kube_binpacking_node_capacity",
+		"kube_binpacking_node_reserved_ratio",
+		"kube_binpacking_group_reserved_ratio",
+		"kube_binpacking_cluster_capacity",
+	); err != nil {
+		t.Error(err)
+	}
+}
 
-		collector := NewBinpackingCollector(nodeLister, podLister, logger, resources, labelGroups, true, nil, nil)
+// TestBinpackingCollector_PendingPods tests the demand of unscheduled pods,
+// cluster-wide and by namespace.
+func TestBinpackingCollector_PendingPods(t *testing.T) {
+	nodes := []*corev1.Node{makeNode("node-1", "4", "8Gi")}
 
-		ch := make(chan prometheus.Metric, 200)
-		collector.Collect(ch)
-		close(ch)
+	oldPending := makePodWithResources("team-a", "old", "", corev1.PodPending,
+		[]corev1.Container{makeContainer("app", "1", "1Gi")},
+		[]corev1.Container{makeContainer("init", "2", "")})
+	oldPending.CreationTimestamp = metav1.NewTime(time.Now().Add(-10 * time.Minute))
+	newPending := makePodWithResources("team-b", "new", "", corev1.PodPending,
+		[]corev1.Container{makeContainer("app", "500m", "512Mi")}, nil)
+	newPending.CreationTimestamp = metav1.NewTime(time.Now().Add(-1 * time.Minute))
 
-		var groupMetricCount int
-		for m := range ch {
-			desc := m.Desc().String()
-			if stringContains(desc, "kube_binpacking_group_node_count") {
-				groupMetricCount++
-			}
+	pods := []*corev1.Pod{
+		oldPending,
+		newPending,
+		makePodWithResources("team-a", "scheduled", "node-1", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "1", "1Gi")}, nil),
+		makePodWithResources("team-a", "failed", "", corev1.PodFailed,
+			[]corev1.Container{makeContainer("app", "8", "8Gi")}, nil),
+	}
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	resources := []corev1.ResourceName{corev1.ResourceCPU}
+	collector := NewBinpackingCollector(
+		&fakeNodeLister{nodes: nodes}, &fakePodLister{pods: pods},
+		logger, resources, nil, false, nil, nil,
+		CollectorOptions{EnablePendingByNamespace: true},
+	)
+
+	expected := `
+# HELP kube_binpacking_namespace_pending_pods Number of unscheduled pending pods in this namespace
+# TYPE kube_binpacking_namespace_pending_pods gauge
+kube_binpacking_namespace_pending_pods{namespace="team-a"} 1
+kube_binpacking_namespace_pending_pods{namespace="team-b"} 1
+# HELP kube_binpacking_namespace_pending_requested Total resource requested by unscheduled pending pods in this namespace
+# TYPE kube_binpacking_namespace_pending_requested gauge
+kube_binpacking_namespace_pending_requested{namespace="team-a",resource="cpu"} 2
+kube_binpacking_namespace_pending_requested{namespace="team-b",resource="cpu"} 0.5
+# HELP kube_binpacking_pending_pods Number of pending pods that are not yet scheduled to a node
+# TYPE kube_binpacking_pending_pods gauge
+kube_binpacking_pending_pods 2
+# HELP kube_binpacking_pending_requested Total resource requested by pending pods that are not yet scheduled to a node
+# TYPE kube_binpacking_pending_requested gauge
+kube_binpacking_pending_requested{resource="cpu"} 2.5
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"kube_binpacking_pending_pods",
+		"kube_binpacking_pending_requested",
+		"kube_binpacking_namespace_pending_pods",
+		"kube_binpacking_namespace_pending_requested",
+	); err != nil {
+		t.Error(err)
+	}
+
+	// The oldest pending pod was created 10 minutes ago.
+	ch := make(chan prometheus.Metric, 100)
+	collector.Collect(ch)
+	close(ch)
+	var found bool
+	for m := range ch {
+		if m.Desc() != pendingOldestAge {
+			continue
 		}
-		// 3 zone values: us-east-1a, us-east-1b, <none>
-		if groupMetricCount != 3 {
-			t.Errorf("expected 3 group_node_count metrics (including <none>), got %d", groupMetricCount)
+		found = true
+		var metric dto.Metric
+		if err := m.Write(&metric); err != nil {
+			t.Fatalf("failed to write metric: %v", err)
 		}
-	})
+		age := metric.GetGauge().GetValue()
+		if age < 600 || age > 660 {
+			t.Errorf("pending_oldest_age_seconds = %v, want ~600", age)
+		}
+	}
+	if !found {
+		t.Error("pending_oldest_age_seconds not emitted")
+	}
 }
 
-// TestBinpackingCollector_DisableNodeMetrics tests that per-node metrics are not emitted when disabled.
-func TestBinpackingCollector_DisableNodeMetrics(t *testing.T) {
-	// Create test data
-	nodes := []*corev1.Node{
+// TestComputeFragmentation tests the largest free chunk and fragmentation
+// index over a set of nodes.
+func TestComputeFragmentation(t *testing.T) {
+	tests := []struct {
+		name        string
+		nodes       [][2]float64 // allocated, allocatable
+		wantLargest float64
+		wantIndex   float64
+	}{
 		{
-			ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
-			Status: corev1.NodeStatus{
-				Allocatable: corev1.ResourceList{
-					corev1.ResourceCPU:    resource.MustParse("4"),
-					corev1.ResourceMemory: resource.MustParse("8Gi"),
-				},
-			},
+			name:        "no nodes",
+			wantLargest: 0,
+			wantIndex:   0,
 		},
 		{
-			ObjectMeta: metav1.ObjectMeta{Name: "node-2"},
-			Status: corev1.NodeStatus{
-				Allocatable: corev1.ResourceList{
-					corev1.ResourceCPU:    resource.MustParse("4"),
-					corev1.ResourceMemory: resource.MustParse("8Gi"),
-				},
-			},
+			name:        "single node",
+			nodes:       [][2]float64{{1, 4}},
+			wantLargest: 3,
+			wantIndex:   0,
+		},
+		{
+			name:        "free spread evenly",
+			nodes:       [][2]float64{{3, 4}, {3, 4}, {3, 4}, {3, 4}},
+			wantLargest: 1,
+			wantIndex:   0.75,
 		},
+		{
+			name:        "free concentrated on one node",
+			nodes:       [][2]float64{{4, 4}, {0, 4}},
+			wantLargest: 4,
+			wantIndex:   0,
+		},
+		{
+			name:        "over-allocated node has nothing free",
+			nodes:       [][2]float64{{6, 4}, {2, 4}},
+			wantLargest: 2,
+			wantIndex:   0,
+		},
+		{
+			name:        "all nodes full",
+			nodes:       [][2]float64{{4, 4}, {4, 4}},
+			wantLargest: 0,
+			wantIndex:   0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var usages []*nodeUsage
+			for _, n := range tt.nodes {
+				usages = append(usages, &nodeUsage{resources: map[corev1.ResourceName]resourceUsage{
+					corev1.ResourceCPU: {allocated: n[0], allocatable: n[1]},
+				}})
+			}
+
+			frag := computeFragmentation(usages, corev1.ResourceCPU)
+			if !floatEquals(frag.largestFree, tt.wantLargest) {
+				t.Errorf("largestFree = %v, want %v", frag.largestFree, tt.wantLargest)
+			}
+			if !floatEquals(frag.index(), tt.wantIndex) {
+				t.Errorf("index() = %v, want %v", frag.index(), tt.wantIndex)
+			}
+		})
+	}
+}
+
+// TestBinpackingCollector_Fragmentation tests that fragmentation is reported
+// per label group and cluster-wide.
+func TestBinpackingCollector_Fragmentation(t *testing.T) {
+	nodes := []*corev1.Node{
+		makeNode("a-1", "4", "8Gi"),
+		makeNode("a-2", "4", "8Gi"),
+		makeNode("b-1", "8", "8Gi"),
 	}
+	nodes[0].Labels = map[string]string{"zone": "a"}
+	nodes[1].Labels = map[string]string{"zone": "a"}
+	nodes[2].Labels = map[string]string{"zone": "b"}
 
 	pods := []*corev1.Pod{
-		{
-			ObjectMeta: metav1.ObjectMeta{Name: "pod-1", Namespace: "default"},
-			Spec: corev1.PodSpec{
-				NodeName:   "node-1",
-				Containers: []corev1.Container{makeContainer("app", "1000m", "2Gi")},
-			},
-			Status: corev1.PodStatus{Phase: corev1.PodRunning},
-		},
+		makePodWithResources("default", "a-1-pod", "a-1", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "2", "")}, nil),
+		makePodWithResources("default", "a-2-pod", "a-2", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "2", "")}, nil),
+		makePodWithResources("default", "b-1-pod", "b-1", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "4", "")}, nil),
 	}
 
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
-	nodeLister := &fakeNodeLister{nodes: nodes}
-	podLister := &fakePodLister{pods: pods}
+	resources := []corev1.ResourceName{corev1.ResourceCPU}
+	collector := NewBinpackingCollector(
+		&fakeNodeLister{nodes: nodes}, &fakePodLister{pods: pods},
+		logger, resources, [][]string{{"zone"}}, false, nil, nil, CollectorOptions{},
+	)
 
-	resources := []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory}
+	// Zone a: 2 + 2 free, a 4-CPU pod does not fit. Zone b: 4 free on one node.
+	expected := `
+# HELP kube_binpacking_cluster_fragmentation_index Cluster-wide fragmentation of free resource: 1 - largest free on a single node / total free (0 = all free resource on one node)
+# TYPE kube_binpacking_cluster_fragmentation_index gauge
+kube_binpacking_cluster_fragmentation_index{resource="cpu"} 0.5
+# HELP kube_binpacking_cluster_largest_free Largest unallocated resource (allocatable minus allocated) on any single node in the cluster
+# TYPE kube_binpacking_cluster_largest_free gauge
+kube_binpacking_cluster_largest_free{resource="cpu"} 4
+# HELP kube_binpacking_group_fragmentation_index Fragmentation of free resource in this label group: 1 - largest free on a single node / total free (0 = all free resource on one node)
+# TYPE kube_binpacking_group_fragmentation_index gauge
+kube_binpacking_group_fragmentation_index{label_group="zone",label_group_value="a",resource="cpu"} 0.5
+kube_binpacking_group_fragmentation_index{label_group="zone",label_group_value="b",resource="cpu"} 0
+# HELP kube_binpacking_group_largest_free Largest unallocated resource (allocatable minus allocated) on any single node in this label group
+# TYPE kube_binpacking_group_largest_free gauge
+kube_binpacking_group_largest_free{label_group="zone",label_group_value="a",resource="cpu"} 2
+kube_binpacking_group_largest_free{label_group="zone",label_group_value="b",resource="cpu"} 4
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"kube_binpacking_cluster_largest_free",
+		"kube_binpacking_cluster_fragmentation_index",
+		"kube_binpacking_group_largest_free",
+		"kube_binpacking_group_fragmentation_index",
+	); err != nil {
+		t.Error(err)
+	}
+}
 
-	// Create collector with node metrics DISABLED
-	collector := NewBinpackingCollector(nodeLister, podLister, logger, resources, nil, false, nil, nil)
+// TestBinpackingCollector_Stranded tests that free resource on nodes where
+// another tracked resource is saturated is reported as stranded.
+func TestBinpackingCollector_Stranded(t *testing.T) {
+	nodes := []*corev1.Node{makeNode("cpu-full", "4", "8Gi"), makeNode("balanced", "4", "8Gi")}
+	nodes[0].Labels = map[string]string{"zone": "a"}
+	nodes[1].Labels = map[string]string{"zone": "a"}
 
-	ch := make(chan prometheus.Metric, 100)
-	collector.Collect(ch)
-	close(ch)
+	pods := []*corev1.Pod{
+		// 3.8/4 CPU (95%) with 4Gi of 8Gi memory free.
+		makePodWithResources("default", "cpu-heavy", "cpu-full", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "3800m", "4Gi")}, nil),
+		// 50% CPU, 50% memory: nothing stranded.
+		makePodWithResources("default", "balanced", "balanced", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "2", "4Gi")}, nil),
+	}
 
-	// Count metric types
-	var nodeMetricCount, clusterMetricCount int
-	for m := range ch {
-		desc := m.Desc().String()
-		if stringContains(desc, "kube_binpacking_node_") {
-			nodeMetricCount++
-		}
-		if stringContains(desc, "kube_binpacking_cluster_") {
-			clusterMetricCount++
-		}
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	resources := []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory}
+	collector := NewBinpackingCollector(
+		&fakeNodeLister{nodes: nodes}, &fakePodLister{pods: pods},
+		logger, resources, [][]string{{"zone"}}, true, nil, nil, CollectorOptions{},
+	)
+
+	expected := `
+# HELP kube_binpacking_cluster_stranded_ratio Cluster-wide ratio of stranded resource to allocatable
+# TYPE kube_binpacking_cluster_stranded_ratio gauge
+kube_binpacking_cluster_stranded_ratio{resource="cpu"} 0
+kube_binpacking_cluster_stranded_ratio{resource="memory"} 0.25
+# HELP kube_binpacking_group_stranded Unallocated resource on nodes in this label group where another tracked resource is saturated
+# TYPE kube_binpacking_group_stranded gauge
+kube_binpacking_group_stranded{label_group="zone",label_group_value="a",resource="cpu"} 0
+kube_binpacking_group_stranded{label_group="zone",label_group_value="a",resource="memory"} 4.294967296e+09
+# HELP kube_binpacking_node_stranded Unallocated resource on this node that is stranded because another tracked resource is saturated
+# TYPE kube_binpacking_node_stranded gauge
+kube_binpacking_node_stranded{node="balanced",resource="cpu"} 0
+kube_binpacking_node_stranded{node="balanced",resource="memory"} 0
+kube_binpacking_node_stranded{node="cpu-full",resource="cpu"} 0
+kube_binpacking_node_stranded{node="cpu-full",resource="memory"} 4.294967296e+09
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"kube_binpacking_node_stranded",
+		"kube_binpacking_group_stranded",
+		"kube_binpacking_cluster_stranded_ratio",
+	); err != nil {
+		t.Error(err)
 	}
 
-	// Should have NO node metrics
-	if nodeMetricCount > 0 {
-		t.Errorf("Expected 0 node metrics when disabled, got %d", nodeMetricCount)
+	// A higher threshold no longer considers 95% CPU saturated.
+	collector = NewBinpackingCollector(
+		&fakeNodeLister{nodes: nodes}, &fakePodLister{pods: pods},
+		logger, resources, nil, false, nil, nil, CollectorOptions{StrandedThreshold: 0.99},
+	)
+	expected = `
+# HELP kube_binpacking_cluster_stranded Cluster-wide unallocated resource on nodes where another tracked resource is saturated
+# TYPE kube_binpacking_cluster_stranded gauge
+kube_binpacking_cluster_stranded{resource="cpu"} 0
+kube_binpacking_cluster_stranded{resource="memory"} 0
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"kube_binpacking_cluster_stranded",
+	); err != nil {
+		t.Error(err)
 	}
+}
 
-	// Should still have cluster metrics (5 metrics × 2 resources + 1 node_count = 11)
-	expectedClusterMetrics := 11
-	if clusterMetricCount != expectedClusterMetrics {
-		t.Errorf("Expected %d cluster metrics, got %d", expectedClusterMetrics, clusterMetricCount)
+// TestBinpackingCollector_StrandedAlias tests that an alias is not stranded
+// by its own saturated source resource.
+func TestBinpackingCollector_StrandedAlias(t *testing.T) {
+	node := makeNode("gpu-node", "8", "32Gi")
+	node.Status.Allocatable["nvidia.com/gpu"] = resource.MustParse("2")
+	node.Status.Allocatable["amd.com/gpu"] = resource.MustParse("2")
+	pod := makePodWithResources("default", "trainer", "gpu-node", corev1.PodRunning,
+		[]corev1.Container{makeContainer("app", "1", "1Gi")}, nil)
+	pod.Spec.Containers[0].Resources.Requests["nvidia.com/gpu"] = resource.MustParse("2")
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	resources := []corev1.ResourceName{"nvidia.com/gpu"}
+	collector := NewBinpackingCollector(
+		&fakeNodeLister{nodes: []*corev1.Node{node}}, &fakePodLister{pods: []*corev1.Pod{pod}},
+		logger, resources, nil, true, nil, nil,
+		CollectorOptions{ResourceAliases: []ResourceAlias{{
+			Name:    "gpu",
+			Sources: []AliasSource{{Resource: "nvidia.com/gpu", Weight: 1}, {Resource: "amd.com/gpu", Weight: 1}},
+		}}},
+	)
+
+	expected := `
+# HELP kube_binpacking_node_stranded Unallocated resource on this node that is stranded because another tracked resource is saturated
+# TYPE kube_binpacking_node_stranded gauge
+kube_binpacking_node_stranded{node="gpu-node",resource="gpu"} 0
+kube_binpacking_node_stranded{node="gpu-node",resource="nvidia.com/gpu"} 0
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"kube_binpacking_node_stranded",
+	); err != nil {
+		t.Error(err)
 	}
 }
 
-// TestBinpackingCollector_EnableNodeMetrics tests that per-node metrics ARE emitted when enabled.
-func TestBinpackingCollector_EnableNodeMetrics(t *testing.T) {
-	// Create test data
-	nodes := []*corev1.Node{
+// TestDominantUtilization tests the selection of the most utilized resource.
+func TestDominantUtilization(t *testing.T) {
+	resources := []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory, "nvidia.com/gpu"}
+	tests := []struct {
+		name         string
+		usage        map[corev1.ResourceName]resourceUsage
+		wantResource corev1.ResourceName
+		wantRatio    float64
+	}{
 		{
-			ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
-			Status: corev1.NodeStatus{
-				Allocatable: corev1.ResourceList{
-					corev1.ResourceCPU: resource.MustParse("4"),
-				},
+			name: "cpu dominates",
+			usage: map[corev1.ResourceName]resourceUsage{
+				corev1.ResourceCPU:    {allocated: 3, allocatable: 4},
+				corev1.ResourceMemory: {allocated: 4, allocatable: 8},
 			},
+			wantResource: corev1.ResourceCPU,
+			wantRatio:    0.75,
 		},
-	}
-
-	pods := []*corev1.Pod{
 		{
-			ObjectMeta: metav1.ObjectMeta{Name: "pod-1", Namespace: "default"},
-			Spec: corev1.PodSpec{
-				NodeName:   "node-1",
-				Containers: []corev1.Container{makeContainer("app", "1000m", "2Gi")},
+			name: "memory dominates",
+			usage: map[corev1.ResourceName]resourceUsage{
+				corev1.ResourceCPU:    {allocated: 1, allocatable: 4},
+				corev1.ResourceMemory: {allocated: 7, allocatable: 8},
 			},
-			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+			wantResource: corev1.ResourceMemory,
+			wantRatio:    0.875,
+		},
+		{
+			name: "tie keeps first resource",
+			usage: map[corev1.ResourceName]resourceUsage{
+				corev1.ResourceCPU:    {allocated: 2, allocatable: 4},
+				corev1.ResourceMemory: {allocated: 4, allocatable: 8},
+			},
+			wantResource: corev1.ResourceCPU,
+			wantRatio:    0.5,
+		},
+		{
+			name: "resource without allocatable is ignored",
+			usage: map[corev1.ResourceName]resourceUsage{
+				corev1.ResourceCPU:    {allocated: 0, allocatable: 4},
+				corev1.ResourceMemory: {allocated: 0, allocatable: 8},
+				"nvidia.com/gpu":      {allocated: 1, allocatable: 0},
+			},
+			wantResource: corev1.ResourceCPU,
+			wantRatio:    0,
+		},
+		{
+			name:         "no allocatable",
+			usage:        map[corev1.ResourceName]resourceUsage{},
+			wantResource: "",
+			wantRatio:    0,
 		},
 	}
 
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
-	nodeLister := &fakeNodeLister{nodes: nodes}
-	podLister := &fakePodLister{pods: pods}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotResource, gotRatio := dominantUtilization(&nodeUsage{resources: tt.usage}, resources)
+			if gotResource != tt.wantResource {
+				t.Errorf("dominantUtilization() resource = %q, want %q", gotResource, tt.wantResource)
+			}
+			if !floatEquals(gotRatio, tt.wantRatio) {
+				t.Errorf("dominantUtilization() ratio = %v, want %v", gotRatio, tt.wantRatio)
+			}
+		})
+	}
+}
 
-	resources := []corev1.ResourceName{corev1.ResourceCPU}
+// TestBinpackingCollector_DominantUtilization tests the per-node dominant
+// utilization and its group and cluster averages.
+func TestBinpackingCollector_DominantUtilization(t *testing.T) {
+	nodes := []*corev1.Node{
+		makeNode("node-1", "4", "8Gi"),
+		makeNode("node-2", "4", "8Gi"),
+		makeNode("empty", "", ""),
+	}
+	for _, node := range nodes {
+		node.Labels = map[string]string{"zone": "a"}
+	}
 
-	// Create collector with node metrics ENABLED (default)
-	collector := NewBinpackingCollector(nodeLister, podLister, logger, resources, nil, true, nil, nil)
+	pods := []*corev1.Pod{
+		makePodWithResources("default", "cpu-heavy", "node-1", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "3", "2Gi")}, nil),
+		makePodWithResources("default", "memory-heavy", "node-2", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "1", "4Gi")}, nil),
+	}
 
-	ch := make(chan prometheus.Metric, 100)
-	collector.Collect(ch)
-	close(ch)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	resources := []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory}
+	collector := NewBinpackingCollector(
+		&fakeNodeLister{nodes: nodes}, &fakePodLister{pods: pods},
+		logger, resources, [][]string{{"zone"}}, true, nil, nil, CollectorOptions{},
+	)
 
-	// Count metric types
-	var nodeMetricCount int
-	for m := range ch {
-		desc := m.Desc().String()
-		if stringContains(desc, "kube_binpacking_node_") {
-			nodeMetricCount++
-		}
+	expected := `
+# HELP kube_binpacking_cluster_dominant_utilization_ratio Average of the per-node dominant utilization ratio across the cluster
+# TYPE kube_binpacking_cluster_dominant_utilization_ratio gauge
+kube_binpacking_cluster_dominant_utilization_ratio 0.625
+# HELP kube_binpacking_group_dominant_utilization_ratio Average of the per-node dominant utilization ratio for nodes in this label group
+# TYPE kube_binpacking_group_dominant_utilization_ratio gauge
+kube_binpacking_group_dominant_utilization_ratio{label_group="zone",label_group_value="a"} 0.625
+# HELP kube_binpacking_node_dominant_utilization_ratio Highest utilization ratio across the tracked resources on this node, labelled with the resource it comes from
+# TYPE kube_binpacking_node_dominant_utilization_ratio gauge
+kube_binpacking_node_dominant_utilization_ratio{dominant_resource="cpu",node="node-1"} 0.75
+kube_binpacking_node_dominant_utilization_ratio{dominant_resource="memory",node="node-2"} 0.5
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"kube_binpacking_node_dominant_utilization_ratio",
+		"kube_binpacking_group_dominant_utilization_ratio",
+		"kube_binpacking_cluster_dominant_utilization_ratio",
+	); err != nil {
+		t.Error(err)
 	}
+}
 
-	// Should have node metrics (1 node × 5 metrics × 1 resource = 5)
-	expectedNodeMetrics := 5
-	if nodeMetricCount != expectedNodeMetrics {
-		t.Errorf("Expected %d node metrics when enabled, got %d", expectedNodeMetrics, nodeMetricCount)
+// TestNativeBucketIndex tests the native histogram bucket index computation.
+func TestNativeBucketIndex(t *testing.T) {
+	tests := []struct {
+		value float64
+		want  int
+	}{
+		{value: 1, want: 0},
+		{value: 0.5, want: -8},
+		{value: 2, want: 8},
+		{value: 0.75, want: -3}, // (2^(-4/8), 2^(-3/8)] = (0.707, 0.771]
+		{value: 0.7, want: -4},  // (2^(-5/8), 2^(-4/8)] = (0.648, 0.707]
+		{value: 1.05, want: 1},  // (1, 2^(1/8)] = (1, 1.091]
+	}
+
+	for _, tt := range tests {
+		got := nativeBucketIndex(tt.value, nativeHistogramSchema)
+		if got != tt.want {
+			t.Errorf("nativeBucketIndex(%v) = %d, want %d", tt.value, got, tt.want)
+		}
 	}
 }
 
-// TestBinpackingCollector_LabelGrouping_NoLabels tests that no group metrics are emitted when no label groups are configured.
-func TestBinpackingCollector_LabelGrouping_NoLabels(t *testing.T) {
+// TestBinpackingCollector_UtilizationHistogram tests the classic and native
+// node utilization distributions per label group and cluster-wide.
+func TestBinpackingCollector_UtilizationHistogram(t *testing.T) {
 	nodes := []*corev1.Node{
-		{
-			ObjectMeta: metav1.ObjectMeta{
-				Name: "node-1",
-				Labels: map[string]string{
-					"zone": "us-east-1a",
-				},
-			},
-			Status: corev1.NodeStatus{
-				Allocatable: corev1.ResourceList{
-					corev1.ResourceCPU: resource.MustParse("4"),
-				},
-			},
-		},
+		makeNode("a-1", "4", "8Gi"),
+		makeNode("a-2", "4", "8Gi"),
+		makeNode("b-1", "4", "8Gi"),
+		makeNode("no-cpu", "", "8Gi"),
 	}
+	nodes[0].Labels = map[string]string{"zone": "a"}
+	nodes[1].Labels = map[string]string{"zone": "a"}
+	nodes[2].Labels = map[string]string{"zone": "b"}
+	nodes[3].Labels = map[string]string{"zone": "b"}
 
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
-	nodeLister := &fakeNodeLister{nodes: nodes}
-	podLister := &fakePodLister{pods: nil}
+	pods := []*corev1.Pod{
+		makePodWithResources("default", "a-1-pod", "a-1", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "1", "")}, nil),
+		makePodWithResources("default", "a-2-pod", "a-2", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "3", "")}, nil),
+		makePodWithResources("default", "b-1-pod", "b-1", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "5", "")}, nil),
+	}
 
-	// No label groups configured
-	labelGroups := [][]string{}
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
 	resources := []corev1.ResourceName{corev1.ResourceCPU}
 
-	collector := NewBinpackingCollector(nodeLister, podLister, logger, resources, labelGroups, true, nil, nil)
+	t.Run("classic", func(t *testing.T) {
+		collector := NewBinpackingCollector(
+			&fakeNodeLister{nodes: nodes}, &fakePodLister{pods: pods},
+			logger, resources, [][]string{{"zone"}}, false, nil, nil,
+			CollectorOptions{UtilizationHistogram: HistogramClassic},
+		)
+
+		// Zone a: 0.25 and 0.75. Zone b: 1.25 (over-allocated). no-cpu has no CPU allocatable.
+		expected := `
+# HELP kube_binpacking_group_node_utilization Distribution of node utilization ratios (allocated/allocatable) for nodes in this label group
+# TYPE kube_binpacking_group_node_utilization histogram
+kube_binpacking_group_node_utilization_bucket{label_group="zone",label_group_value="a",resource="cpu",le="0.1"} 0
+kube_binpacking_group_node_utilization_bucket{label_group="zone",label_group_value="a",resource="cpu",le="0.2"} 0
+kube_binpacking_group_node_utilization_bucket{label_group="zone",label_group_value="a",resource="cpu",le="0.3"} 1
+kube_binpacking_group_node_utilization_bucket{label_group="zone",label_group_value="a",resource="cpu",le="0.4"} 1
+kube_binpacking_group_node_utilization_bucket{label_group="zone",label_group_value="a",resource="cpu",le="0.5"} 1
+kube_binpacking_group_node_utilization_bucket{label_group="zone",label_group_value="a",resource="cpu",le="0.6"} 1
+kube_binpacking_group_node_utilization_bucket{label_group="zone",label_group_value="a",resource="cpu",le="0.7"} 1
+kube_binpacking_group_node_utilization_bucket{label_group="zone",label_group_value="a",resource="cpu",le="0.8"} 2
+kube_binpacking_group_node_utilization_bucket{label_group="zone",label_group_value="a",resource="cpu",le="0.9"} 2
+kube_binpacking_group_node_utilization_bucket{label_group="zone",label_group_value="a",resource="cpu",le="1"} 2
+kube_binpacking_group_node_utilization_bucket{label_group="zone",label_group_value="a",resource="cpu",le="+Inf"} 2
+kube_binpacking_group_node_utilization_sum{label_group="zone",label_group_value="a",resource="cpu"} 1
+kube_binpacking_group_node_utilization_count{label_group="zone",label_group_value="a",resource="cpu"} 2
+kube_binpacking_group_node_utilization_bucket{label_group="zone",label_group_value="b",resource="cpu",le="0.1"} 0
+kube_binpacking_group_node_utilization_bucket{label_group="zone",label_group_value="b",resource="cpu",le="0.2"} 0
+kube_binpacking_group_node_utilization_bucket{label_group="zone",label_group_value="b",resource="cpu",le="0.3"} 0
+kube_binpacking_group_node_utilization_bucket{label_group="zone",label_group_value="b",resource="cpu",le="0.4"} 0
+kube_binpacking_group_node_utilization_bucket{label_group="zone",label_group_value="b",resource="cpu",le="0.5"} 0
+kube_binpacking_group_node_utilization_bucket{label_group="zone",label_group_value="b",resource="cpu",le="0.6"} 0
+kube_binpacking_group_node_utilization_bucket{label_group="zone",label_group_value="b",resource="cpu",le="0.7"} 0
+kube_binpacking_group_node_utilization_bucket{label_group="zone",label_group_value="b",resource="cpu",le="0.8"} 0
+kube_binpacking_group_node_utilization_bucket{label_group="zone",label_group_value="b",resource="cpu",le="0.9"} 0
+kube_binpacking_group_node_utilization_bucket{label_group="zone",label_group_value="b",resource="cpu",le="1"} 0
+kube_binpacking_group_node_utilization_bucket{label_group="zone",label_group_value="b",resource="cpu",le="+Inf"} 1
+kube_binpacking_group_node_utilization_sum{label_group="zone",label_group_value="b",resource="cpu"} 1.25
+kube_binpacking_group_node_utilization_count{label_group="zone",label_group_value="b",resource="cpu"} 1
+`
+		if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+			"kube_binpacking_group_node_utilization",
+		); err != nil {
+			t.Error(err)
+		}
 
-	ch := make(chan prometheus.Metric, 50)
-	collector.Collect(ch)
-	close(ch)
+		if count := testutil.CollectAndCount(collector, "kube_binpacking_cluster_node_utilization"); count != 1 {
+			t.Errorf("Expected 1 cluster histogram, got %d", count)
+		}
+	})
 
-	// Verify no group metrics
-	for m := range ch {
-		desc := m.Desc().String()
-		if stringContains(desc, "kube_binpacking_group_") {
-			t.Error("Expected no group metrics when label groups not configured")
+	t.Run("native", func(t *testing.T) {
+		collector := NewBinpackingCollector(
+			&fakeNodeLister{nodes: nodes}, &fakePodLister{pods: pods},
+			logger, resources, nil, false, nil, nil,
+			CollectorOptions{UtilizationHistogram: HistogramNative},
+		)
+
+		ch := make(chan prometheus.Metric, 100)
+		collector.Collect(ch)
+		close(ch)
+		var found bool
+		for m := range ch {
+			if m.Desc() != clusterNodeUtilizationHistogram {
+				continue
+			}
+			found = true
+			var metric dto.Metric
+			if err := m.Write(&metric); err != nil {
+				t.Fatalf("failed to write metric: %v", err)
+			}
+			h := metric.GetHistogram()
+			if h.GetSampleCount() != 3 {
+				t.Errorf("sample count = %d, want 3", h.GetSampleCount())
+			}
+			if !floatEquals(h.GetSampleSum(), 2.25) {
+				t.Errorf("sample sum = %v, want 2.25", h.GetSampleSum())
+			}
+			if h.GetSchema() != nativeHistogramSchema {
+				t.Errorf("schema = %d, want %d", h.GetSchema(), nativeHistogramSchema)
+			}
+			if len(h.GetPositiveSpan()) == 0 {
+				t.Error("expected native histogram positive spans")
+			}
+		}
+		if !found {
+			t.Error("cluster node utilization histogram not emitted")
+		}
+	})
+}
+
+func TestQuantileAndStddev(t *testing.T) {
+	sorted := []float64{0.1, 0.2, 0.4, 0.9}
+
+	tests := []struct {
+		q    float64
+		want float64
+	}{
+		{q: 0, want: 0.1},
+		{q: 0.5, want: 0.3},
+		{q: 0.9, want: 0.75},
+		{q: 1, want: 0.9},
+	}
+	for _, tt := range tests {
+		if got := quantile(sorted, tt.q); !floatEquals(got, tt.want) {
+			t.Errorf("quantile(%v) = %v, want %v", tt.q, got, tt.want)
 		}
 	}
+
+	if got := quantile([]float64{0.4}, 0.9); got != 0.4 {
+		t.Errorf("quantile of single value = %v, want 0.4", got)
+	}
+	if got := stddev([]float64{0.25, 0.75}); !floatEquals(got, 0.25) {
+		t.Errorf("stddev = %v, want 0.25", got)
+	}
+	if got := stddev(nil); got != 0 {
+		t.Errorf("stddev(nil) = %v, want 0", got)
+	}
 }
 
-// TestBinpackingCollector_LeaderElection_Disabled verifies that when isLeader is nil
-// (leader election not enabled), all metrics are emitted and no leader_status metric appears.
-func TestBinpackingCollector_LeaderElection_Disabled(t *testing.T) {
-	nodes := []*corev1.Node{makeNode("node-1", "4", "8Gi")}
+// TestBinpackingCollector_GroupUtilizationSummary tests the per-group
+// quantiles and standard deviation of node utilization ratios.
+func TestBinpackingCollector_GroupUtilizationSummary(t *testing.T) {
+	nodes := []*corev1.Node{
+		makeNode("a-1", "4", "8Gi"),
+		makeNode("a-2", "4", "8Gi"),
+		makeNode("b-1", "", "8Gi"),
+	}
+	nodes[0].Labels = map[string]string{"zone": "a"}
+	nodes[1].Labels = map[string]string{"zone": "a"}
+	nodes[2].Labels = map[string]string{"zone": "b"}
+
 	pods := []*corev1.Pod{
-		makePodWithResources("default", "pod-1", "node-1", corev1.PodRunning,
-			[]corev1.Container{makeContainer("app", "1", "2Gi")}, nil),
+		makePodWithResources("default", "a-1-pod", "a-1", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "1", "")}, nil),
+		makePodWithResources("default", "a-2-pod", "a-2", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "3", "")}, nil),
 	}
 
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
-	resources := []corev1.ResourceName{corev1.ResourceCPU}
-	syncInfo := &SyncInfo{LastSyncTime: time.Now().Add(-10 * time.Second)}
-
 	collector := NewBinpackingCollector(
 		&fakeNodeLister{nodes: nodes}, &fakePodLister{pods: pods},
-		logger, resources, nil, true, syncInfo, nil, // isLeader = nil
+		logger, []corev1.ResourceName{corev1.ResourceCPU}, [][]string{{"zone"}}, false, nil, nil,
+		CollectorOptions{},
 	)
 
-	ch := make(chan prometheus.Metric, 100)
-	collector.Collect(ch)
-	close(ch)
-
-	var hasLeaderStatus, hasClusterAllocated, hasCacheAge bool
-	for m := range ch {
-		desc := m.Desc().String()
-		if contains(desc, "kube_binpacking_leader_status") {
-			hasLeaderStatus = true
-		}
-		if contains(desc, "kube_binpacking_cluster_allocated") {
-			hasClusterAllocated = true
-		}
-		if contains(desc, "kube_binpacking_cache_age_seconds") {
-			hasCacheAge = true
-		}
+	// Zone a: 0.25 and 0.75. Zone b has no CPU allocatable and is skipped.
+	expected := `
+# HELP kube_binpacking_group_node_utilization_ratio_quantile Quantile of the node utilization ratios in this label group (0 = min, 0.5 = median, 0.9 = p90, 1 = max)
+# TYPE kube_binpacking_group_node_utilization_ratio_quantile gauge
+kube_binpacking_group_node_utilization_ratio_quantile{label_group="zone",label_group_value="a",quantile="0",resource="cpu"} 0.25
+kube_binpacking_group_node_utilization_ratio_quantile{label_group="zone",label_group_value="a",quantile="0.5",resource="cpu"} 0.5
+kube_binpacking_group_node_utilization_ratio_quantile{label_group="zone",label_group_value="a",quantile="0.9",resource="cpu"} 0.7
+kube_binpacking_group_node_utilization_ratio_quantile{label_group="zone",label_group_value="a",quantile="1",resource="cpu"} 0.75
+# HELP kube_binpacking_group_node_utilization_ratio_stddev Standard deviation of the node utilization ratios in this label group, an indicator of imbalance
+# TYPE kube_binpacking_group_node_utilization_ratio_stddev gauge
+kube_binpacking_group_node_utilization_ratio_stddev{label_group="zone",label_group_value="a",resource="cpu"} 0.25
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"kube_binpacking_group_node_utilization_ratio_quantile",
+		"kube_binpacking_group_node_utilization_ratio_stddev",
+	); err != nil {
+		t.Error(err)
 	}
+}
 
-	if hasLeaderStatus {
-		t.Error("leader_status metric should NOT be emitted when leader election is disabled")
-	}
-	if !hasClusterAllocated {
-		t.Error("cluster_allocated metric should be emitted when leader election is disabled")
+// TestBinpackingCollector_Consolidation tests consolidation candidates and
+// underutilized node counts in dominant and per-resource mode.
+func TestBinpackingCollector_Consolidation(t *testing.T) {
+	nodes := []*corev1.Node{
+		makeNode("node-1", "4", "8Gi"),
+		makeNode("node-2", "4", "8Gi"),
+		makeNode("empty", "", ""),
 	}
-	if !hasCacheAge {
-		t.Error("cache_age metric should be emitted when leader election is disabled")
+	for _, node := range nodes {
+		node.Labels = map[string]string{"zone": "a"}
 	}
-}
 
-// TestBinpackingCollector_LeaderElection_IsLeader verifies that when this instance is the leader,
-// all binpacking metrics are emitted along with leader_status = 1.
-func TestBinpackingCollector_LeaderElection_IsLeader(t *testing.T) {
-	nodes := []*corev1.Node{makeNode("node-1", "4", "8Gi")}
+	// node-1: cpu 0.75, memory 0.25. node-2: cpu 0.25, memory 0.5.
 	pods := []*corev1.Pod{
-		makePodWithResources("default", "pod-1", "node-1", corev1.PodRunning,
-			[]corev1.Container{makeContainer("app", "1", "2Gi")}, nil),
+		makePodWithResources("default", "cpu-heavy", "node-1", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "3", "2Gi")}, nil),
+		makePodWithResources("default", "memory-heavy", "node-2", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "1", "4Gi")}, nil),
 	}
 
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
-	resources := []corev1.ResourceName{corev1.ResourceCPU}
-	syncInfo := &SyncInfo{LastSyncTime: time.Now().Add(-10 * time.Second)}
+	resources := []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory}
 
-	isLeader := new(atomic.Bool)
-	isLeader.Store(true) // this instance IS the leader
+	tests := []struct {
+		name     string
+		mode     ConsolidationMode
+		expected string
+	}{
+		{
+			name: "dominant",
+			mode: ConsolidationDominant,
+			expected: `
+# HELP kube_binpacking_cluster_underutilized_nodes Number of nodes whose utilization is below the consolidation threshold; resource is "dominant" in dominant mode
+# TYPE kube_binpacking_cluster_underutilized_nodes gauge
+kube_binpacking_cluster_underutilized_nodes{resource="dominant"} 1
+# HELP kube_binpacking_group_underutilized_nodes Number of nodes in this label group whose utilization is below the consolidation threshold; resource is "dominant" in dominant mode
+# TYPE kube_binpacking_group_underutilized_nodes gauge
+kube_binpacking_group_underutilized_nodes{label_group="zone",label_group_value="a",resource="dominant"} 1
+# HELP kube_binpacking_node_consolidation_candidate Whether the node's utilization is below the consolidation threshold (1) or not (0); resource is "dominant" in dominant mode
+# TYPE kube_binpacking_node_consolidation_candidate gauge
+kube_binpacking_node_consolidation_candidate{node="node-1",resource="dominant"} 0
+kube_binpacking_node_consolidation_candidate{node="node-2",resource="dominant"} 1
+`,
+		},
+		{
+			name: "per-resource",
+			mode: ConsolidationPerResource,
+			expected: `
+# HELP kube_binpacking_cluster_underutilized_nodes Number of nodes whose utilization is below the consolidation threshold; resource is "dominant" in dominant mode
+# TYPE kube_binpacking_cluster_underutilized_nodes gauge
+kube_binpacking_cluster_underutilized_nodes{resource="cpu"} 1
+kube_binpacking_cluster_underutilized_nodes{resource="memory"} 2
+# HELP kube_binpacking_group_underutilized_nodes Number of nodes in this label group whose utilization is below the consolidation threshold; resource is "dominant" in dominant mode
+# TYPE kube_binpacking_group_underutilized_nodes gauge
+kube_binpacking_group_underutilized_nodes{label_group="zone",label_group_value="a",resource="cpu"} 1
+kube_binpacking_group_underutilized_nodes{label_group="zone",label_group_value="a",resource="memory"} 2
+# HELP kube_binpacking_node_consolidation_candidate Whether the node's utilization is below the consolidation threshold (1) or not (0); resource is "dominant" in dominant mode
+# TYPE kube_binpacking_node_consolidation_candidate gauge
+kube_binpacking_node_consolidation_candidate{node="node-1",resource="cpu"} 0
+kube_binpacking_node_consolidation_candidate{node="node-1",resource="memory"} 1
+kube_binpacking_node_consolidation_candidate{node="node-2",resource="cpu"} 1
+kube_binpacking_node_consolidation_candidate{node="node-2",resource="memory"} 1
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collector := NewBinpackingCollector(
+				&fakeNodeLister{nodes: nodes}, &fakePodLister{pods: pods},
+				logger, resources, [][]string{{"zone"}}, true, nil, nil,
+				CollectorOptions{ConsolidationThreshold: 0.6, ConsolidationMode: tt.mode},
+			)
+			if err := testutil.CollectAndCompare(collector, strings.NewReader(tt.expected),
+				"kube_binpacking_node_consolidation_candidate",
+				"kube_binpacking_group_underutilized_nodes",
+				"kube_binpacking_cluster_underutilized_nodes",
+			); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		collector := NewBinpackingCollector(
+			&fakeNodeLister{nodes: nodes}, &fakePodLister{pods: pods},
+			logger, resources, [][]string{{"zone"}}, true, nil, nil, CollectorOptions{},
+		)
+		if count := testutil.CollectAndCount(collector, "kube_binpacking_node_consolidation_candidate"); count != 0 {
+			t.Errorf("Expected no consolidation metrics by default, got %d", count)
+		}
+	})
+}
+
+// TestBinpackingCollector_NodesRequired tests the consolidation simulation
+// per label group.
+func TestBinpackingCollector_NodesRequired(t *testing.T) {
+	nodes := []*corev1.Node{
+		makeNode("a-1", "4", "8Gi"),
+		makeNode("a-2", "4", "8Gi"),
+		makeNode("a-3", "4", "8Gi"),
+		makeNode("b-1", "4", "8Gi"),
+		makeNode("c-1", "4", "8Gi"),
+	}
+	for _, node := range nodes {
+		node.Labels = map[string]string{"zone": node.Name[:1]}
+	}
+
+	var pods []*corev1.Pod
+	for _, node := range nodes {
+		pods = append(pods, makeDaemonSetPod("kube-system", "ds-"+node.Name, node.Name, "500m", "512Mi"))
+	}
+	// Zone a: three 2-CPU pods on 3.5 free CPU per node fit on two nodes by
+	// total demand, but no two pods share a node.
+	for i, node := range []string{"a-1", "a-2", "a-3"} {
+		pods = append(pods, makePodWithResources("default", "a-pod-"+strconv.Itoa(i), node, corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "2", "1Gi")}, nil))
+	}
+	// Zone b is over-allocated and needs an extra node.
+	pods = append(pods,
+		makePodWithResources("default", "b-pod-1", "b-1", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "3", "1Gi")}, nil),
+		makePodWithResources("default", "b-pod-2", "b-1", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "3", "1Gi")}, nil),
+	)
 
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	resources := []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory}
 	collector := NewBinpackingCollector(
 		&fakeNodeLister{nodes: nodes}, &fakePodLister{pods: pods},
-		logger, resources, nil, true, syncInfo, isLeader,
+		logger, resources, [][]string{{"zone"}}, false, nil, nil,
+		CollectorOptions{SimulateConsolidation: true},
 	)
 
-	ch := make(chan prometheus.Metric, 100)
-	collector.Collect(ch)
-	close(ch)
-
-	var hasLeaderStatus, hasClusterAllocated, hasNodeAllocated, hasCacheAge bool
-	for m := range ch {
-		desc := m.Desc().String()
-		if contains(desc, "kube_binpacking_leader_status") {
-			hasLeaderStatus = true
-		}
-		if contains(desc, "kube_binpacking_cluster_allocated") {
-			hasClusterAllocated = true
-		}
-		if contains(desc, "kube_binpacking_node_allocated") {
-			hasNodeAllocated = true
-		}
-		if contains(desc, "kube_binpacking_cache_age_seconds") {
-			hasCacheAge = true
-		}
+	// Zone c only runs DaemonSet pods.
+	expected := `
+# HELP kube_binpacking_group_nodes_required Simulated number of this label group's nodes needed to host its pods other than DaemonSet and static pods, by estimation method
+# TYPE kube_binpacking_group_nodes_required gauge
+kube_binpacking_group_nodes_required{label_group="zone",label_group_value="a",method="first_fit_decreasing"} 3
+kube_binpacking_group_nodes_required{label_group="zone",label_group_value="a",method="lower_bound"} 2
+kube_binpacking_group_nodes_required{label_group="zone",label_group_value="b",method="first_fit_decreasing"} 2
+kube_binpacking_group_nodes_required{label_group="zone",label_group_value="b",method="lower_bound"} 2
+kube_binpacking_group_nodes_required{label_group="zone",label_group_value="c",method="first_fit_decreasing"} 0
+kube_binpacking_group_nodes_required{label_group="zone",label_group_value="c",method="lower_bound"} 0
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"kube_binpacking_group_nodes_required",
+	); err != nil {
+		t.Error(err)
 	}
+}
 
-	if !hasLeaderStatus {
-		t.Error("leader_status metric should be emitted when leader election is enabled")
+// TestNodesRequired_PacksSmallPods tests that first-fit-decreasing fills the
+// largest nodes first.
+func TestNodesRequired_PacksSmallPods(t *testing.T) {
+	usages := []*nodeUsage{
+		{
+			node:      &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "small"}},
+			resources: map[corev1.ResourceName]resourceUsage{corev1.ResourceCPU: {allocatable: 2}},
+			workloadRequests: []map[corev1.ResourceName]float64{
+				{corev1.ResourceCPU: 1},
+			},
+		},
+		{
+			node:      &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "large"}},
+			resources: map[corev1.ResourceName]resourceUsage{corev1.ResourceCPU: {allocatable: 8}},
+			workloadRequests: []map[corev1.ResourceName]float64{
+				{corev1.ResourceCPU: 3},
+				{corev1.ResourceCPU: 2},
+				{},
+			},
+		},
 	}
-	if !hasClusterAllocated {
-		t.Error("cluster_allocated should be emitted when this instance is leader")
+
+	lowerBound, firstFit := nodesRequired(usages, []corev1.ResourceName{corev1.ResourceCPU})
+	if lowerBound != 1 || firstFit != 1 {
+		t.Errorf("nodesRequired() = (%d, %d), want (1, 1)", lowerBound, firstFit)
 	}
-	if !hasNodeAllocated {
-		t.Error("node_allocated should be emitted when this instance is leader")
+}
+
+// TestNodeState tests node state detection from the node spec, conditions,
+// taints and deletion timestamp.
+func TestNodeState(t *testing.T) {
+	now := metav1.Now()
+	notReady := []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionFalse}}
+
+	tests := []struct {
+		name string
+		node *corev1.Node
+		want string
+	}{
+		{
+			name: "no conditions",
+			node: &corev1.Node{},
+			want: nodeStateSchedulable,
+		},
+		{
+			name: "ready",
+			node: &corev1.Node{Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}}},
+			want: nodeStateSchedulable,
+		},
+		{
+			name: "cordoned",
+			node: &corev1.Node{Spec: corev1.NodeSpec{Unschedulable: true}},
+			want: nodeStateCordoned,
+		},
+		{
+			name: "unschedulable taint",
+			node: &corev1.Node{Spec: corev1.NodeSpec{Taints: []corev1.Taint{{Key: corev1.TaintNodeUnschedulable, Effect: corev1.TaintEffectNoSchedule}}}},
+			want: nodeStateCordoned,
+		},
+		{
+			name: "not ready condition",
+			node: &corev1.Node{Status: corev1.NodeStatus{Conditions: notReady}},
+			want: nodeStateNotReady,
+		},
+		{
+			name: "unreachable taint",
+			node: &corev1.Node{Spec: corev1.NodeSpec{Taints: []corev1.Taint{{Key: corev1.TaintNodeUnreachable, Effect: corev1.TaintEffectNoExecute}}}},
+			want: nodeStateNotReady,
+		},
+		{
+			name: "not ready takes precedence over cordoned",
+			node: &corev1.Node{Spec: corev1.NodeSpec{Unschedulable: true}, Status: corev1.NodeStatus{Conditions: notReady}},
+			want: nodeStateNotReady,
+		},
+		{
+			name: "cluster autoscaler scale-down",
+			node: &corev1.Node{Spec: corev1.NodeSpec{Unschedulable: true, Taints: []corev1.Taint{{Key: "ToBeDeletedByClusterAutoscaler", Effect: corev1.TaintEffectNoSchedule}}}},
+			want: nodeStateDeleting,
+		},
+		{
+			name: "karpenter disruption",
+			node: &corev1.Node{Spec: corev1.NodeSpec{Taints: []corev1.Taint{{Key: "karpenter.sh/disrupted", Effect: corev1.TaintEffectNoSchedule}}}},
+			want: nodeStateDeleting,
+		},
+		{
+			name: "deletion timestamp",
+			node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &now}, Status: corev1.NodeStatus{Conditions: notReady}},
+			want: nodeStateDeleting,
+		},
+		{
+			name: "prefer no schedule taint ignored",
+			node: &corev1.Node{Spec: corev1.NodeSpec{Taints: []corev1.Taint{{Key: corev1.TaintNodeUnschedulable, Effect: corev1.TaintEffectPreferNoSchedule}}}},
+			want: nodeStateSchedulable,
+		},
 	}
-	if !hasCacheAge {
-		t.Error("cache_age should be emitted when this instance is leader")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nodeState(tt.node); got != tt.want {
+				t.Errorf("nodeState() = %q, want %q", got, tt.want)
+			}
+		})
 	}
 }
 
-// TestBinpackingCollector_LeaderElection_IsStandby verifies that when this instance is NOT the leader,
-// only cache_age and leader_status (= 0) are emitted — no binpacking metrics.
-func TestBinpackingCollector_LeaderElection_IsStandby(t *testing.T) {
-	nodes := []*corev1.Node{makeNode("node-1", "4", "8Gi")}
+// TestBinpackingCollector_NodeStateMode tests how cordoned, not ready and
+// deleting nodes are aggregated in each node state mode.
+func TestBinpackingCollector_NodeStateMode(t *testing.T) {
+	now := metav1.Now()
+	nodes := []*corev1.Node{
+		makeNode("ready", "4", "8Gi"),
+		makeNode("cordoned", "4", "8Gi"),
+		makeNode("deleting", "4", "8Gi"),
+	}
+	nodes[1].Spec.Unschedulable = true
+	nodes[2].DeletionTimestamp = &now
+	for _, node := range nodes {
+		node.Labels = map[string]string{"zone": "a"}
+	}
+
 	pods := []*corev1.Pod{
-		makePodWithResources("default", "pod-1", "node-1", corev1.PodRunning,
-			[]corev1.Container{makeContainer("app", "1", "2Gi")}, nil),
+		makePodWithResources("default", "ready-pod", "ready", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "2", "")}, nil),
+		makePodWithResources("default", "cordoned-pod", "cordoned", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "1", "")}, nil),
 	}
 
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
 	resources := []corev1.ResourceName{corev1.ResourceCPU}
-	syncInfo := &SyncInfo{LastSyncTime: time.Now().Add(-10 * time.Second)}
 
-	isLeader := new(atomic.Bool)
-	isLeader.Store(false) // this instance is standby
+	stateCounts := `
+# HELP kube_binpacking_cluster_node_state_count Number of nodes in the cluster by node state
+# TYPE kube_binpacking_cluster_node_state_count gauge
+kube_binpacking_cluster_node_state_count{node_state="cordoned"} 1
+kube_binpacking_cluster_node_state_count{node_state="deleting"} 1
+kube_binpacking_cluster_node_state_count{node_state="not_ready"} 0
+kube_binpacking_cluster_node_state_count{node_state="schedulable"} 1
+# HELP kube_binpacking_group_node_state_count Number of nodes in this label group by node state
+# TYPE kube_binpacking_group_node_state_count gauge
+kube_binpacking_group_node_state_count{label_group="zone",label_group_value="a",node_state="cordoned"} 1
+kube_binpacking_group_node_state_count{label_group="zone",label_group_value="a",node_state="deleting"} 1
+kube_binpacking_group_node_state_count{label_group="zone",label_group_value="a",node_state="not_ready"} 0
+kube_binpacking_group_node_state_count{label_group="zone",label_group_value="a",node_state="schedulable"} 1
+# HELP kube_binpacking_group_node_count Number of nodes in this label group
+# TYPE kube_binpacking_group_node_count gauge
+kube_binpacking_group_node_count{label_group="zone",label_group_value="a"} 3
+`
 
-	collector := NewBinpackingCollector(
-		&fakeNodeLister{nodes: nodes}, &fakePodLister{pods: pods},
-		logger, resources, nil, true, syncInfo, isLeader,
-	)
+	tests := []struct {
+		name     string
+		mode     NodeStateMode
+		expected string
+	}{
+		{
+			name: "include",
+			mode: NodeStateInclude,
+			expected: `
+# HELP kube_binpacking_cluster_allocatable Cluster-wide total allocatable resource
+# TYPE kube_binpacking_cluster_allocatable gauge
+kube_binpacking_cluster_allocatable{resource="cpu"} 12
+# HELP kube_binpacking_group_allocated Total resource requested by pods on nodes in this label group
+# TYPE kube_binpacking_group_allocated gauge
+kube_binpacking_group_allocated{label_group="zone",label_group_value="a",resource="cpu"} 3
+` + stateCounts,
+		},
+		{
+			name: "exclude",
+			mode: NodeStateExclude,
+			expected: `
+# HELP kube_binpacking_cluster_allocatable Cluster-wide total allocatable resource
+# TYPE kube_binpacking_cluster_allocatable gauge
+kube_binpacking_cluster_allocatable{resource="cpu"} 4
+# HELP kube_binpacking_group_allocated Total resource requested by pods on nodes in this label group
+# TYPE kube_binpacking_group_allocated gauge
+kube_binpacking_group_allocated{label_group="zone",label_group_value="a",resource="cpu"} 2
+` + stateCounts,
+		},
+		{
+			name: "separate",
+			mode: NodeStateSeparate,
+			expected: `
+# HELP kube_binpacking_cluster_allocatable Cluster-wide total allocatable resource
+# TYPE kube_binpacking_cluster_allocatable gauge
+kube_binpacking_cluster_allocatable{resource="cpu"} 4
+# HELP kube_binpacking_group_allocated Total resource requested by pods on nodes in this label group
+# TYPE kube_binpacking_group_allocated gauge
+kube_binpacking_group_allocated{label_group="zone",label_group_value="a",resource="cpu"} 2
+# HELP kube_binpacking_cluster_node_state_allocatable Total allocatable resource of nodes in this node state across the cluster
+# TYPE kube_binpacking_cluster_node_state_allocatable gauge
+kube_binpacking_cluster_node_state_allocatable{node_state="cordoned",resource="cpu"} 4
+kube_binpacking_cluster_node_state_allocatable{node_state="deleting",resource="cpu"} 4
+kube_binpacking_cluster_node_state_allocatable{node_state="not_ready",resource="cpu"} 0
+kube_binpacking_cluster_node_state_allocatable{node_state="schedulable",resource="cpu"} 4
+# HELP kube_binpacking_group_node_state_allocated Total resource requested by pods on nodes in this label group and node state
+# TYPE kube_binpacking_group_node_state_allocated gauge
+kube_binpacking_group_node_state_allocated{label_group="zone",label_group_value="a",node_state="cordoned",resource="cpu"} 1
+kube_binpacking_group_node_state_allocated{label_group="zone",label_group_value="a",node_state="deleting",resource="cpu"} 0
+kube_binpacking_group_node_state_allocated{label_group="zone",label_group_value="a",node_state="not_ready",resource="cpu"} 0
+kube_binpacking_group_node_state_allocated{label_group="zone",label_group_value="a",node_state="schedulable",resource="cpu"} 2
+` + stateCounts,
+		},
+	}
 
-	ch := make(chan prometheus.Metric, 100)
-	collector.Collect(ch)
-	close(ch)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collector := NewBinpackingCollector(
+				&fakeNodeLister{nodes: nodes}, &fakePodLister{pods: pods},
+				logger, resources, [][]string{{"zone"}}, false, nil, nil,
+				CollectorOptions{NodeStateMode: tt.mode},
+			)
+			metricNames := []string{
+				"kube_binpacking_cluster_allocatable",
+				"kube_binpacking_group_allocated",
+				"kube_binpacking_cluster_node_state_count",
+				"kube_binpacking_group_node_state_count",
+				"kube_binpacking_group_node_count",
+			}
+			if tt.mode == NodeStateSeparate {
+				metricNames = append(metricNames,
+					"kube_binpacking_cluster_node_state_allocatable",
+					"kube_binpacking_group_node_state_allocated",
+				)
+			}
+			if err := testutil.CollectAndCompare(collector, strings.NewReader(tt.expected), metricNames...); err != nil {
+				t.Error(err)
+			}
+		})
+	}
 
-	var hasLeaderStatus, hasClusterAllocated, hasNodeAllocated, hasCacheAge bool
-	var metricCount int
-	for m := range ch {
-		metricCount++
-		desc := m.Desc().String()
-		if contains(desc, "kube_binpacking_leader_status") {
-			hasLeaderStatus = true
-		}
-		if contains(desc, "kube_binpacking_cluster_allocated") {
-			hasClusterAllocated = true
-		}
-		if contains(desc, "kube_binpacking_node_allocated") {
-			hasNodeAllocated = true
-		}
-		if contains(desc, "kube_binpacking_cache_age_seconds") {
-			hasCacheAge = true
+	// The cluster utilization histogram only covers aggregated nodes: the
+	// ready node at 0.5, not the cordoned (0.25) or deleting (0) ones.
+	t.Run("exclude histogram", func(t *testing.T) {
+		collector := NewBinpackingCollector(
+			&fakeNodeLister{nodes: nodes}, &fakePodLister{pods: pods},
+			logger, resources, nil, false, nil, nil,
+			CollectorOptions{NodeStateMode: NodeStateExclude, UtilizationHistogram: HistogramClassic},
+		)
+		expected := `
+# HELP kube_binpacking_cluster_node_utilization Distribution of node utilization ratios (allocated/allocatable) across the cluster
+# TYPE kube_binpacking_cluster_node_utilization histogram
+kube_binpacking_cluster_node_utilization_bucket{resource="cpu",le="0.1"} 0
+kube_binpacking_cluster_node_utilization_bucket{resource="cpu",le="0.2"} 0
+kube_binpacking_cluster_node_utilization_bucket{resource="cpu",le="0.3"} 0
+kube_binpacking_cluster_node_utilization_bucket{resource="cpu",le="0.4"} 0
+kube_binpacking_cluster_node_utilization_bucket{resource="cpu",le="0.5"} 1
+kube_binpacking_cluster_node_utilization_bucket{resource="cpu",le="0.6"} 1
+kube_binpacking_cluster_node_utilization_bucket{resource="cpu",le="0.7"} 1
+kube_binpacking_cluster_node_utilization_bucket{resource="cpu",le="0.8"} 1
+kube_binpacking_cluster_node_utilization_bucket{resource="cpu",le="0.9"} 1
+kube_binpacking_cluster_node_utilization_bucket{resource="cpu",le="1"} 1
+kube_binpacking_cluster_node_utilization_bucket{resource="cpu",le="+Inf"} 1
+kube_binpacking_cluster_node_utilization_sum{resource="cpu"} 0.5
+kube_binpacking_cluster_node_utilization_count{resource="cpu"} 1
+`
+		if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+			"kube_binpacking_cluster_node_utilization",
+		); err != nil {
+			t.Error(err)
 		}
-	}
+	})
+}
 
-	if !hasLeaderStatus {
-		t.Error("leader_status should be emitted on standby")
+// TestBinpackingCollector_TerminatingPods tests the terminating allocated
+// breakdown and excluding terminating pods from allocated.
+func TestBinpackingCollector_TerminatingPods(t *testing.T) {
+	now := metav1.Now()
+	nodes := []*corev1.Node{makeNode("node-1", "4", "8Gi")}
+
+	terminating := makePodWithResources("default", "old", "node-1", corev1.PodRunning,
+		[]corev1.Container{makeContainer("app", "1", "")}, nil)
+	terminating.DeletionTimestamp = &now
+	pods := []*corev1.Pod{
+		makePodWithResources("default", "new", "node-1", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "2", "")}, nil),
+		terminating,
 	}
-	if !hasCacheAge {
-		t.Error("cache_age should be emitted on standby")
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	resources := []corev1.ResourceName{corev1.ResourceCPU}
+
+	tests := []struct {
+		name     string
+		exclude  bool
+		expected string
+	}{
+		{
+			name: "included",
+			expected: `
+# HELP kube_binpacking_node_allocated Total resource requested by pods on this node
+# TYPE kube_binpacking_node_allocated gauge
+kube_binpacking_node_allocated{node="node-1",resource="cpu"} 3
+# HELP kube_binpacking_node_terminating_allocated Total resource requested by terminating pods (with a deletion timestamp) on this node
+# TYPE kube_binpacking_node_terminating_allocated gauge
+kube_binpacking_node_terminating_allocated{node="node-1",resource="cpu"} 1
+# HELP kube_binpacking_cluster_terminating_allocated Cluster-wide total resource requested by terminating pods (with a deletion timestamp)
+# TYPE kube_binpacking_cluster_terminating_allocated gauge
+kube_binpacking_cluster_terminating_allocated{resource="cpu"} 1
+`,
+		},
+		{
+			name:    "excluded",
+			exclude: true,
+			expected: `
+# HELP kube_binpacking_node_allocated Total resource requested by pods on this node
+# TYPE kube_binpacking_node_allocated gauge
+kube_binpacking_node_allocated{node="node-1",resource="cpu"} 2
+# HELP kube_binpacking_node_terminating_allocated Total resource requested by terminating pods (with a deletion timestamp) on this node
+# TYPE kube_binpacking_node_terminating_allocated gauge
+kube_binpacking_node_terminating_allocated{node="node-1",resource="cpu"} 1
+# HELP kube_binpacking_cluster_terminating_allocated Cluster-wide total resource requested by terminating pods (with a deletion timestamp)
+# TYPE kube_binpacking_cluster_terminating_allocated gauge
+kube_binpacking_cluster_terminating_allocated{resource="cpu"} 1
+`,
+		},
 	}
-	if hasClusterAllocated {
-		t.Error("cluster_allocated should NOT be emitted on standby")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collector := NewBinpackingCollector(
+				&fakeNodeLister{nodes: nodes}, &fakePodLister{pods: pods},
+				logger, resources, nil, true, nil, nil,
+				CollectorOptions{ExcludeTerminatingPods: tt.exclude},
+			)
+			if err := testutil.CollectAndCompare(collector, strings.NewReader(tt.expected),
+				"kube_binpacking_node_allocated",
+				"kube_binpacking_node_terminating_allocated",
+				"kube_binpacking_cluster_terminating_allocated",
+			); err != nil {
+				t.Error(err)
+			}
+		})
 	}
-	if hasNodeAllocated {
-		t.Error("node_allocated should NOT be emitted on standby")
+}
+
+// TestBinpackingCollector_NamespaceAllocation tests the per-namespace
+// allocated breakdown and the top-N limit with the <other> bucket.
+func TestBinpackingCollector_NamespaceAllocation(t *testing.T) {
+	nodes := []*corev1.Node{
+		makeNode("a-1", "8", "16Gi"),
+		makeNode("b-1", "8", "16Gi"),
 	}
-	// Should only have 2 metrics: cache_age + leader_status
-	if metricCount != 2 {
-		t.Errorf("standby should emit exactly 2 metrics (cache_age + leader_status), got %d", metricCount)
+	nodes[0].Labels = map[string]string{"zone": "a"}
+	nodes[1].Labels = map[string]string{"zone": "b"}
+
+	pods := []*corev1.Pod{
+		makePodWithResources("team-a", "a-1", "a-1", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "3", "")}, nil),
+		makePodWithResources("team-b", "b-1", "a-1", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "2", "")}, nil),
+		makePodWithResources("team-c", "c-1", "a-1", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "500m", "")}, nil),
+		makePodWithResources("team-d", "d-1", "a-1", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "250m", "")}, nil),
+		makePodWithResources("team-a", "a-2", "b-1", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "1", "")}, nil),
+		makePodWithResources("team-e", "e-1", "b-1", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "", "1Gi")}, nil),
 	}
-}
 
-// Helper function to check if a string contains a substring.
-func stringContains(s, substr string) bool {
-	return len(s) >= len(substr) && (s == substr || len(s) > len(substr) &&
-		(s[:len(substr)] == substr || s[len(s)-len(substr):] == substr ||
-		containsAt(s, substr)))
-}
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	collector := NewBinpackingCollector(
+		&fakeNodeLister{nodes: nodes}, &fakePodLister{pods: pods},
+		logger, []corev1.ResourceName{corev1.ResourceCPU}, [][]string{{"zone"}}, false, nil, nil,
+		CollectorOptions{EnableNamespaceAllocation: true, NamespaceTopN: 2},
+	)
 
-func containsAt(s, substr string) bool {
-	for i := 0; i <= len(s)-len(substr); i++ {
-		if s[i:i+len(substr)] == substr {
-			return true
-		}
+	// team-e requests no CPU and is left out.
+	expected := `
+# HELP kube_binpacking_cluster_namespace_allocated Cluster-wide total resource requested by pods in this namespace; namespaces outside the top N are summed as <other>
+# TYPE kube_binpacking_cluster_namespace_allocated gauge
+kube_binpacking_cluster_namespace_allocated{namespace="<other>",resource="cpu"} 0.75
+kube_binpacking_cluster_namespace_allocated{namespace="team-a",resource="cpu"} 4
+kube_binpacking_cluster_namespace_allocated{namespace="team-b",resource="cpu"} 2
+# HELP kube_binpacking_group_namespace_allocated Total resource requested by pods in this namespace on nodes in this label group; namespaces outside the top N are summed as <other>
+# TYPE kube_binpacking_group_namespace_allocated gauge
+kube_binpacking_group_namespace_allocated{label_group="zone",label_group_value="a",namespace="<other>",resource="cpu"} 0.75
+kube_binpacking_group_namespace_allocated{label_group="zone",label_group_value="a",namespace="team-a",resource="cpu"} 3
+kube_binpacking_group_namespace_allocated{label_group="zone",label_group_value="a",namespace="team-b",resource="cpu"} 2
+kube_binpacking_group_namespace_allocated{label_group="zone",label_group_value="b",namespace="team-a",resource="cpu"} 1
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"kube_binpacking_cluster_namespace_allocated",
+		"kube_binpacking_group_namespace_allocated",
+	); err != nil {
+		t.Error(err)
 	}
-	return false
 }
 
-// Helper function to create an error for testing.
-func someError(msg string) error {
-	return &testError{msg: msg}
-}
+// TestPodWorkloadKind tests classification of pods by their owner.
+func TestPodWorkloadKind(t *testing.T) {
+	controller := true
+	owned := func(refs ...metav1.OwnerReference) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{OwnerReferences: refs}}
+	}
 
-type testError struct {
-	msg string
-}
+	tests := []struct {
+		name string
+		pod  *corev1.Pod
+		want int
+	}{
+		{name: "bare", pod: owned(), want: workloadBare},
+		{name: "daemonset", pod: owned(metav1.OwnerReference{Kind: "DaemonSet"}), want: workloadDaemonSet},
+		{name: "replicaset", pod: owned(metav1.OwnerReference{Kind: "ReplicaSet"}), want: workloadReplicaSet},
+		{name: "statefulset", pod: owned(metav1.OwnerReference{Kind: "StatefulSet"}), want: workloadStatefulSet},
+		{name: "job", pod: owned(metav1.OwnerReference{Kind: "Job"}), want: workloadJob},
+		{name: "mirror pod", pod: owned(metav1.OwnerReference{Kind: "Node"}), want: workloadStatic},
+		{
+			name: "mirror annotation",
+			pod:  &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{corev1.MirrorPodAnnotationKey: "abc"}}},
+			want: workloadStatic,
+		},
+		{name: "custom controller", pod: owned(metav1.OwnerReference{Kind: "Rollout"}), want: workloadOther},
+		{
+			name: "controller owner wins",
+			pod: owned(
+				metav1.OwnerReference{Kind: "ConfigMap"},
+				metav1.OwnerReference{Kind: "StatefulSet", Controller: &controller},
+			),
+			want: workloadStatefulSet,
+		},
+	}
 
-func (e *testError) Error() string {
-	return e.msg
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := podWorkloadKind(tt.pod); got != tt.want {
+				t.Errorf("podWorkloadKind() = %s, want %s", workloadKindNames[got], workloadKindNames[tt.want])
+			}
+		})
+	}
 }
 
-// Helper function to check if a string contains a substring.
-func contains(s, substr string) bool {
-	return len(s) >= len(substr) && (s == substr || len(s) > len(substr) && findSubstring(s, substr))
-}
+// TestBinpackingCollector_WorkloadKinds tests the allocated breakdown by
+// workload kind.
+func TestBinpackingCollector_WorkloadKinds(t *testing.T) {
+	nodes := []*corev1.Node{makeNode("node-1", "8", "16Gi")}
 
-func findSubstring(s, substr string) bool {
-	for i := 0; i <= len(s)-len(substr); i++ {
-		if s[i:i+len(substr)] == substr {
-			return true
-		}
+	deployment := makePodWithResources("default", "web", "node-1", corev1.PodRunning,
+		[]corev1.Container{makeContainer("app", "2", "")}, nil)
+	deployment.OwnerReferences = []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "web-abc"}}
+	pods := []*corev1.Pod{
+		makeDaemonSetPod("kube-system", "agent", "node-1", "500m", ""),
+		deployment,
+		makePodWithResources("default", "debug", "node-1", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "1", "")}, nil),
 	}
-	return false
-}
 
-// makeDaemonSetPod creates a pod owned by a DaemonSet with specified resources.
-func makeDaemonSetPod(namespace, name, nodeName string, cpu, memory string) *corev1.Pod {
-	pod := makePodWithResources(namespace, name, nodeName, corev1.PodRunning,
-		[]corev1.Container{makeContainer("ds-container", cpu, memory)}, nil)
-	pod.OwnerReferences = []metav1.OwnerReference{
-		{
-			APIVersion: "apps/v1",
-			Kind:       "DaemonSet",
-			Name:       name + "-ds",
-		},
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	collector := NewBinpackingCollector(
+		&fakeNodeLister{nodes: nodes}, &fakePodLister{pods: pods},
+		logger, []corev1.ResourceName{corev1.ResourceCPU}, nil, true, nil, nil,
+		CollectorOptions{EnableWorkloadKindMetrics: true},
+	)
+
+	expected := `
+# HELP kube_binpacking_cluster_workload_allocated Cluster-wide total resource requested by pods by the kind of workload owning them
+# TYPE kube_binpacking_cluster_workload_allocated gauge
+kube_binpacking_cluster_workload_allocated{resource="cpu",workload_kind="bare"} 1
+kube_binpacking_cluster_workload_allocated{resource="cpu",workload_kind="daemonset"} 0.5
+kube_binpacking_cluster_workload_allocated{resource="cpu",workload_kind="job"} 0
+kube_binpacking_cluster_workload_allocated{resource="cpu",workload_kind="other"} 0
+kube_binpacking_cluster_workload_allocated{resource="cpu",workload_kind="replicaset"} 2
+kube_binpacking_cluster_workload_allocated{resource="cpu",workload_kind="static"} 0
+kube_binpacking_cluster_workload_allocated{resource="cpu",workload_kind="statefulset"} 0
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"kube_binpacking_cluster_workload_allocated",
+	); err != nil {
+		t.Error(err)
+	}
+
+	if count := testutil.CollectAndCount(collector, "kube_binpacking_node_workload_allocated"); count != int(numWorkloadKinds) {
+		t.Errorf("Expected %d node workload series, got %d", numWorkloadKinds, count)
 	}
-	return pod
 }
 
-// TestIsDaemonSetPod tests detection of DaemonSet-owned pods via OwnerReferences.
-func TestIsDaemonSetPod(t *testing.T) {
+// TestIsStaticPod tests detection of static pods via the mirror annotation
+// or a Node owner.
+func TestIsStaticPod(t *testing.T) {
 	tests := []struct {
-		name            string
-		ownerReferences []metav1.OwnerReference
-		want            bool
+		name string
+		pod  *corev1.Pod
+		want bool
 	}{
 		{
-			name: "DaemonSet pod",
-			ownerReferences: []metav1.OwnerReference{
-				{Kind: "DaemonSet", Name: "node-exporter"},
-			},
+			name: "mirror annotation",
+			pod:  &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{corev1.MirrorPodAnnotationKey: "abc"}}},
 			want: true,
 		},
 		{
-			name: "ReplicaSet pod",
-			ownerReferences: []metav1.OwnerReference{
-				{Kind: "ReplicaSet", Name: "my-deploy-abc123"},
-			},
-			want: false,
-		},
-		{
-			name:            "bare pod (no owner)",
-			ownerReferences: nil,
-			want:            false,
+			name: "node owner",
+			pod:  &corev1.Pod{ObjectMeta: metav1.ObjectMeta{OwnerReferences: []metav1.OwnerReference{{Kind: "Node", Name: "node-1"}}}},
+			want: true,
 		},
 		{
-			name: "StatefulSet pod",
-			ownerReferences: []metav1.OwnerReference{
-				{Kind: "StatefulSet", Name: "my-sts"},
-			},
+			name: "daemonset pod",
+			pod:  makeDaemonSetPod("kube-system", "proxy", "node-1", "100m", ""),
 			want: false,
 		},
 		{
-			name: "multiple owners including DaemonSet",
-			ownerReferences: []metav1.OwnerReference{
-				{Kind: "Node", Name: "node-1"},
-				{Kind: "DaemonSet", Name: "fluentbit"},
-			},
-			want: true,
+			name: "bare pod",
+			pod:  &corev1.Pod{},
+			want: false,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			pod := &corev1.Pod{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:            "test-pod",
-					Namespace:       "default",
-					OwnerReferences: tt.ownerReferences,
-				},
-			}
-			if got := isDaemonSetPod(pod); got != tt.want {
-				t.Errorf("isDaemonSetPod() = %v, want %v", got, tt.want)
+			if got := isStaticPod(tt.pod); got != tt.want {
+				t.Errorf("isStaticPod() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
-// TestBinpackingCollector_DaemonSetOverhead tests that DaemonSet overhead metrics
-// are correctly computed for node-level and cluster-level aggregates.
-func TestBinpackingCollector_DaemonSetOverhead(t *testing.T) {
-	nodes := []*corev1.Node{
-		makeNode("node-1", "4", "8Gi"),
-		makeNode("node-2", "8", "16Gi"),
-	}
+// TestBinpackingCollector_StaticPodOverhead tests that static pods are
+// reported as overhead separately from DaemonSet pods.
+func TestBinpackingCollector_StaticPodOverhead(t *testing.T) {
+	nodes := []*corev1.Node{makeNode("node-1", "4", "8Gi")}
 
+	apiserver := makePodWithResources("kube-system", "kube-apiserver-node-1", "node-1", corev1.PodRunning,
+		[]corev1.Container{makeContainer("apiserver", "1", "")}, nil)
+	apiserver.Annotations = map[string]string{corev1.MirrorPodAnnotationKey: "abc"}
 	pods := []*corev1.Pod{
-		// Regular pod on node-1: 1 CPU
-		makePodWithResources("default", "regular-1", "node-1", corev1.PodRunning,
-			[]corev1.Container{makeContainer("app", "1", "2Gi")}, nil),
-		// DaemonSet pod on node-1: 250m CPU
-		makeDaemonSetPod("kube-system", "fluentbit-node1", "node-1", "250m", "512Mi"),
-		// DaemonSet pod on node-2: 250m CPU
-		makeDaemonSetPod("kube-system", "fluentbit-node2", "node-2", "250m", "512Mi"),
-		// Regular pod on node-2: 2 CPU
-		makePodWithResources("default", "regular-2", "node-2", corev1.PodRunning,
-			[]corev1.Container{makeContainer("app", "2", "4Gi")}, nil),
+		apiserver,
+		makeDaemonSetPod("kube-system", "agent", "node-1", "500m", ""),
+		makePodWithResources("default", "web", "node-1", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "2", "")}, nil),
 	}
 
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
-	resources := []corev1.ResourceName{corev1.ResourceCPU}
 	collector := NewBinpackingCollector(
 		&fakeNodeLister{nodes: nodes}, &fakePodLister{pods: pods},
-		logger, resources, nil, true, nil, nil,
+		logger, []corev1.ResourceName{corev1.ResourceCPU}, nil, true, nil, nil, CollectorOptions{},
 	)
 
-	ch := make(chan prometheus.Metric, 100)
-	collector.Collect(ch)
-	close(ch)
+	expected := `
+# HELP kube_binpacking_node_daemonset_overhead Total resource requested by DaemonSet pods on this node
+# TYPE kube_binpacking_node_daemonset_overhead gauge
+kube_binpacking_node_daemonset_overhead{node="node-1",resource="cpu"} 0.5
+# HELP kube_binpacking_node_static_pod_overhead Total resource requested by static (mirror) pods on this node
+# TYPE kube_binpacking_node_static_pod_overhead gauge
+kube_binpacking_node_static_pod_overhead{node="node-1",resource="cpu"} 1
+# HELP kube_binpacking_node_static_pod_overhead_ratio Ratio of static pod overhead to allocatable (0.0-1.0+)
+# TYPE kube_binpacking_node_static_pod_overhead_ratio gauge
+kube_binpacking_node_static_pod_overhead_ratio{node="node-1",resource="cpu"} 0.25
+# HELP kube_binpacking_cluster_static_pod_overhead Cluster-wide total resource requested by static (mirror) pods
+# TYPE kube_binpacking_cluster_static_pod_overhead gauge
+kube_binpacking_cluster_static_pod_overhead{resource="cpu"} 1
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"kube_binpacking_node_daemonset_overhead",
+		"kube_binpacking_node_static_pod_overhead",
+		"kube_binpacking_node_static_pod_overhead_ratio",
+		"kube_binpacking_cluster_static_pod_overhead",
+	); err != nil {
+		t.Error(err)
+	}
+}
 
-	metricCounts := make(map[string]int)
-	for m := range ch {
-		desc := m.Desc().String()
-		switch {
-		case contains(desc, "kube_binpacking_node_daemonset_overhead_ratio"):
-			metricCounts["node_ds_ratio"]++
-		case contains(desc, "kube_binpacking_node_daemonset_overhead"):
-			metricCounts["node_ds_overhead"]++
-		case contains(desc, "kube_binpacking_cluster_daemonset_overhead_ratio"):
-			metricCounts["cluster_ds_ratio"]++
-		case contains(desc, "kube_binpacking_cluster_daemonset_overhead"):
-			metricCounts["cluster_ds_overhead"]++
-		}
+// TestBinpackingCollector_OverheadClasses tests that pods matching an
+// overhead class by namespace or label selector are reported per class,
+// counting each pod once per class even if it matches several matchers.
+func TestBinpackingCollector_OverheadClasses(t *testing.T) {
+	nodes := []*corev1.Node{makeNode("node-1", "4", "8Gi"), makeNode("node-2", "4", "8Gi")}
+	nodes[0].Labels = map[string]string{"pool": "system"}
+	nodes[1].Labels = map[string]string{"pool": "apps"}
+
+	prometheusPod := makePodWithResources("monitoring", "prometheus", "node-1", corev1.PodRunning,
+		[]corev1.Container{makeContainer("prometheus", "1", "")}, nil)
+	prometheusPod.Labels = map[string]string{"platform": "true"}
+	ingress := makePodWithResources("ingress", "controller", "node-2", corev1.PodRunning,
+		[]corev1.Container{makeContainer("nginx", "500m", "")}, nil)
+	ingress.Labels = map[string]string{"platform": "true"}
+	pods := []*corev1.Pod{
+		makeDaemonSetPod("kube-system", "agent-1", "node-1", "200m", ""),
+		makeDaemonSetPod("kube-system", "agent-2", "node-2", "200m", ""),
+		prometheusPod,
+		ingress,
+		makePodWithResources("default", "web", "node-2", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "2", "")}, nil),
 	}
 
-	// 2 nodes × 1 resource = 2 node-level DS metrics each
-	if metricCounts["node_ds_overhead"] != 2 {
-		t.Errorf("node_daemonset_overhead: got %d, want 2", metricCounts["node_ds_overhead"])
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	collector := NewBinpackingCollector(
+		&fakeNodeLister{nodes: nodes}, &fakePodLister{pods: pods},
+		logger, []corev1.ResourceName{corev1.ResourceCPU}, [][]string{{"pool"}}, true, nil, nil,
+		CollectorOptions{OverheadClasses: []OverheadClass{
+			{Name: "platform", Matchers: []OverheadMatcher{
+				{Namespaces: []string{"kube-system", "monitoring"}},
+				{Selector: labels.SelectorFromSet(labels.Set{"platform": "true"})},
+			}},
+			{Name: "ingress", Matchers: []OverheadMatcher{{Namespaces: []string{"ingress"}}}},
+		}},
+	)
+
+	expected := `
+# HELP kube_binpacking_node_class_overhead Total resource requested by pods in this overhead class on this node
+# TYPE kube_binpacking_node_class_overhead gauge
+kube_binpacking_node_class_overhead{node="node-1",overhead_class="ingress",resource="cpu"} 0
+kube_binpacking_node_class_overhead{node="node-1",overhead_class="platform",resource="cpu"} 1.2
+kube_binpacking_node_class_overhead{node="node-2",overhead_class="ingress",resource="cpu"} 0.5
+kube_binpacking_node_class_overhead{node="node-2",overhead_class="platform",resource="cpu"} 0.7
+# HELP kube_binpacking_node_class_overhead_ratio Ratio of overhead class requests to allocatable (0.0-1.0+)
+# TYPE kube_binpacking_node_class_overhead_ratio gauge
+kube_binpacking_node_class_overhead_ratio{node="node-1",overhead_class="ingress",resource="cpu"} 0
+kube_binpacking_node_class_overhead_ratio{node="node-1",overhead_class="platform",resource="cpu"} 0.3
+kube_binpacking_node_class_overhead_ratio{node="node-2",overhead_class="ingress",resource="cpu"} 0.125
+kube_binpacking_node_class_overhead_ratio{node="node-2",overhead_class="platform",resource="cpu"} 0.175
+# HELP kube_binpacking_cluster_class_overhead Cluster-wide total resource requested by pods in this overhead class
+# TYPE kube_binpacking_cluster_class_overhead gauge
+kube_binpacking_cluster_class_overhead{overhead_class="ingress",resource="cpu"} 0.5
+kube_binpacking_cluster_class_overhead{overhead_class="platform",resource="cpu"} 1.9
+# HELP kube_binpacking_group_class_overhead Total resource requested by pods in this overhead class on nodes in this label group
+# TYPE kube_binpacking_group_class_overhead gauge
+kube_binpacking_group_class_overhead{label_group="pool",label_group_value="apps",overhead_class="ingress",resource="cpu"} 0.5
+kube_binpacking_group_class_overhead{label_group="pool",label_group_value="apps",overhead_class="platform",resource="cpu"} 0.7
+kube_binpacking_group_class_overhead{label_group="pool",label_group_value="system",overhead_class="ingress",resource="cpu"} 0
+kube_binpacking_group_class_overhead{label_group="pool",label_group_value="system",overhead_class="platform",resource="cpu"} 1.2
+# HELP kube_binpacking_group_class_overhead_ratio Ratio of overhead class requests to allocatable for nodes in this label group (0.0-1.0+)
+# TYPE kube_binpacking_group_class_overhead_ratio gauge
+kube_binpacking_group_class_overhead_ratio{label_group="pool",label_group_value="apps",overhead_class="ingress",resource="cpu"} 0.125
+kube_binpacking_group_class_overhead_ratio{label_group="pool",label_group_value="apps",overhead_class="platform",resource="cpu"} 0.175
+kube_binpacking_group_class_overhead_ratio{label_group="pool",label_group_value="system",overhead_class="ingress",resource="cpu"} 0
+kube_binpacking_group_class_overhead_ratio{label_group="pool",label_group_value="system",overhead_class="platform",resource="cpu"} 0.3
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"kube_binpacking_node_class_overhead",
+		"kube_binpacking_node_class_overhead_ratio",
+		"kube_binpacking_cluster_class_overhead",
+		"kube_binpacking_group_class_overhead",
+		"kube_binpacking_group_class_overhead_ratio",
+	); err != nil {
+		t.Error(err)
 	}
-	if metricCounts["node_ds_ratio"] != 2 {
-		t.Errorf("node_daemonset_overhead_ratio: got %d, want 2", metricCounts["node_ds_ratio"])
+
+	// Without classes the metrics are not emitted.
+	plain := NewBinpackingCollector(
+		&fakeNodeLister{nodes: nodes}, &fakePodLister{pods: pods},
+		logger, []corev1.ResourceName{corev1.ResourceCPU}, nil, true, nil, nil, CollectorOptions{},
+	)
+	if count := testutil.CollectAndCount(plain, "kube_binpacking_node_class_overhead", "kube_binpacking_cluster_class_overhead"); count != 0 {
+		t.Errorf("Expected no class overhead series without classes, got %d", count)
 	}
-	// 1 resource = 1 cluster-level DS metric each
-	if metricCounts["cluster_ds_overhead"] != 1 {
-		t.Errorf("cluster_daemonset_overhead: got %d, want 1", metricCounts["cluster_ds_overhead"])
+}
+
+// TestPodQOSClass tests the QoS classification, preferring the class
+// reported in the pod status.
+func TestPodQOSClass(t *testing.T) {
+	podWith := func(containers ...corev1.Container) *corev1.Pod {
+		return makePodWithResources("default", "pod", "node-1", corev1.PodRunning, containers, nil)
 	}
-	if metricCounts["cluster_ds_ratio"] != 1 {
-		t.Errorf("cluster_daemonset_overhead_ratio: got %d, want 1", metricCounts["cluster_ds_ratio"])
+	reported := podWith()
+	reported.Status.QOSClass = corev1.PodQOSGuaranteed
+	podLevel := podWith(makeContainer("app", "", ""))
+	podLevel.Spec.Resources = &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1"), corev1.ResourceMemory: resource.MustParse("1Gi")},
+		Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1"), corev1.ResourceMemory: resource.MustParse("1Gi")},
 	}
-}
 
-// TestBinpackingCollector_DaemonSetOverhead_LabelGroups tests that group-level
-// DaemonSet overhead metrics are emitted when label groups are configured.
-func TestBinpackingCollector_DaemonSetOverhead_LabelGroups(t *testing.T) {
-	nodes := []*corev1.Node{
-		{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:   "node-zone-a",
-				Labels: map[string]string{"topology.kubernetes.io/zone": "us-east-1a"},
-			},
-			Status: corev1.NodeStatus{
-				Allocatable: corev1.ResourceList{
-					corev1.ResourceCPU: resource.MustParse("4"),
-				},
-			},
-		},
+	tests := []struct {
+		name string
+		pod  *corev1.Pod
+		want int
+	}{
+		{name: "reported in status", pod: reported, want: qosGuaranteed},
+		{name: "no requests or limits", pod: podWith(makeContainer("app", "", "")), want: qosBestEffort},
+		{name: "requests only", pod: podWith(makeContainer("app", "100m", "")), want: qosBurstable},
+		{name: "requests equal limits", pod: podWith(withLimits(makeContainer("app", "1", "1Gi"), "1", "1Gi")), want: qosGuaranteed},
+		{name: "limits only", pod: podWith(withLimits(makeContainer("app", "", ""), "1", "1Gi")), want: qosGuaranteed},
+		{name: "requests below limits", pod: podWith(withLimits(makeContainer("app", "500m", "1Gi"), "1", "1Gi")), want: qosBurstable},
+		{name: "memory limit missing", pod: podWith(withLimits(makeContainer("app", "1", "1Gi"), "1", "")), want: qosBurstable},
 		{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:   "node-zone-b",
-				Labels: map[string]string{"topology.kubernetes.io/zone": "us-east-1b"},
-			},
-			Status: corev1.NodeStatus{
-				Allocatable: corev1.ResourceList{
-					corev1.ResourceCPU: resource.MustParse("8"),
-				},
-			},
+			name: "one container without limits",
+			pod: podWith(
+				withLimits(makeContainer("app", "1", "1Gi"), "1", "1Gi"),
+				makeContainer("sidecar", "", ""),
+			),
+			want: qosBurstable,
 		},
+		{name: "pod-level resources", pod: podLevel, want: qosGuaranteed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := podQOSClass(tt.pod); got != tt.want {
+				t.Errorf("podQOSClass() = %s, want %s", qosClassNames[got], qosClassNames[tt.want])
+			}
+		})
 	}
+}
+
+// TestBinpackingCollector_QOSClasses tests allocated and pod counts per QoS
+// class at node, cluster and group level.
+func TestBinpackingCollector_QOSClasses(t *testing.T) {
+	nodes := []*corev1.Node{makeNode("node-1", "4", "8Gi")}
+	nodes[0].Labels = map[string]string{"pool": "apps"}
 
 	pods := []*corev1.Pod{
-		makeDaemonSetPod("kube-system", "fluentbit-a", "node-zone-a", "250m", ""),
-		makeDaemonSetPod("kube-system", "fluentbit-b", "node-zone-b", "250m", ""),
-		makePodWithResources("default", "app-a", "node-zone-a", corev1.PodRunning,
-			[]corev1.Container{makeContainer("app", "1", "")}, nil),
+		makePodWithResources("default", "db", "node-1", corev1.PodRunning,
+			[]corev1.Container{withLimits(makeContainer("db", "2", "4Gi"), "2", "4Gi")}, nil),
+		makePodWithResources("default", "web", "node-1", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "500m", "")}, nil),
+		makePodWithResources("default", "batch-1", "node-1", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "", "")}, nil),
+		makePodWithResources("default", "batch-2", "node-1", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "", "")}, nil),
 	}
 
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
-	labelGroups := [][]string{{"topology.kubernetes.io/zone"}}
-	resources := []corev1.ResourceName{corev1.ResourceCPU}
 	collector := NewBinpackingCollector(
 		&fakeNodeLister{nodes: nodes}, &fakePodLister{pods: pods},
-		logger, resources, labelGroups, true, nil, nil,
+		logger, []corev1.ResourceName{corev1.ResourceCPU}, [][]string{{"pool"}}, true, nil, nil,
+		CollectorOptions{EnableQOSMetrics: true},
 	)
 
-	ch := make(chan prometheus.Metric, 200)
-	collector.Collect(ch)
-	close(ch)
-
-	var groupDSOverheadCount, groupDSRatioCount int
-	for m := range ch {
-		desc := m.Desc().String()
-		switch {
-		case contains(desc, "kube_binpacking_group_daemonset_overhead_ratio"):
-			groupDSRatioCount++
-		case contains(desc, "kube_binpacking_group_daemonset_overhead"):
-			groupDSOverheadCount++
-		}
+	expected := `
+# HELP kube_binpacking_node_qos_allocated Total resource requested by pods on this node by pod QoS class
+# TYPE kube_binpacking_node_qos_allocated gauge
+kube_binpacking_node_qos_allocated{node="node-1",qos_class="best_effort",resource="cpu"} 0
+kube_binpacking_node_qos_allocated{node="node-1",qos_class="burstable",resource="cpu"} 0.5
+kube_binpacking_node_qos_allocated{node="node-1",qos_class="guaranteed",resource="cpu"} 2
+# HELP kube_binpacking_node_qos_pods Number of pods on this node by pod QoS class
+# TYPE kube_binpacking_node_qos_pods gauge
+kube_binpacking_node_qos_pods{node="node-1",qos_class="best_effort"} 2
+kube_binpacking_node_qos_pods{node="node-1",qos_class="burstable"} 1
+kube_binpacking_node_qos_pods{node="node-1",qos_class="guaranteed"} 1
+# HELP kube_binpacking_cluster_qos_allocated Cluster-wide total resource requested by pods by pod QoS class
+# TYPE kube_binpacking_cluster_qos_allocated gauge
+kube_binpacking_cluster_qos_allocated{qos_class="best_effort",resource="cpu"} 0
+kube_binpacking_cluster_qos_allocated{qos_class="burstable",resource="cpu"} 0.5
+kube_binpacking_cluster_qos_allocated{qos_class="guaranteed",resource="cpu"} 2
+# HELP kube_binpacking_cluster_qos_pods Cluster-wide number of scheduled pods by pod QoS class
+# TYPE kube_binpacking_cluster_qos_pods gauge
+kube_binpacking_cluster_qos_pods{qos_class="best_effort"} 2
+kube_binpacking_cluster_qos_pods{qos_class="burstable"} 1
+kube_binpacking_cluster_qos_pods{qos_class="guaranteed"} 1
+# HELP kube_binpacking_group_qos_allocated Total resource requested by pods on nodes in this label group by pod QoS class
+# TYPE kube_binpacking_group_qos_allocated gauge
+kube_binpacking_group_qos_allocated{label_group="pool",label_group_value="apps",qos_class="best_effort",resource="cpu"} 0
+kube_binpacking_group_qos_allocated{label_group="pool",label_group_value="apps",qos_class="burstable",resource="cpu"} 0.5
+kube_binpacking_group_qos_allocated{label_group="pool",label_group_value="apps",qos_class="guaranteed",resource="cpu"} 2
+# HELP kube_binpacking_group_qos_pods Number of pods on nodes in this label group by pod QoS class
+# TYPE kube_binpacking_group_qos_pods gauge
+kube_binpacking_group_qos_pods{label_group="pool",label_group_value="apps",qos_class="best_effort"} 2
+kube_binpacking_group_qos_pods{label_group="pool",label_group_value="apps",qos_class="burstable"} 1
+kube_binpacking_group_qos_pods{label_group="pool",label_group_value="apps",qos_class="guaranteed"} 1
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"kube_binpacking_node_qos_allocated",
+		"kube_binpacking_node_qos_pods",
+		"kube_binpacking_cluster_qos_allocated",
+		"kube_binpacking_cluster_qos_pods",
+		"kube_binpacking_group_qos_allocated",
+		"kube_binpacking_group_qos_pods",
+	); err != nil {
+		t.Error(err)
 	}
+}
 
-	// 2 zones × 1 resource = 2 group-level DS metrics each
-	if groupDSOverheadCount != 2 {
-		t.Errorf("group_daemonset_overhead: got %d, want 2", groupDSOverheadCount)
+// TestBinpackingCollector_PriorityClasses tests allocated per PriorityClass
+// and the effective free allocatable per priority threshold, which counts
+// lower-priority requests as preemptible and is floored at zero per node.
+func TestBinpackingCollector_PriorityClasses(t *testing.T) {
+	nodes := []*corev1.Node{makeNode("node-1", "4", "8Gi"), makeNode("node-2", "4", "8Gi")}
+	for _, node := range nodes {
+		node.Labels = map[string]string{"pool": "apps"}
 	}
-	if groupDSRatioCount != 2 {
-		t.Errorf("group_daemonset_overhead_ratio: got %d, want 2", groupDSRatioCount)
-	}
-}
 
-// TestBinpackingCollector_DaemonSetOverhead_DisableNodeMetrics tests that
-// node-level DS metrics are suppressed when node metrics are disabled,
-// but cluster-level DS metrics are still emitted.
-func TestBinpackingCollector_DaemonSetOverhead_DisableNodeMetrics(t *testing.T) {
-	nodes := []*corev1.Node{makeNode("node-1", "4", "8Gi")}
+	withPriority := func(pod *corev1.Pod, class string, priority int32) *corev1.Pod {
+		pod.Spec.PriorityClassName = class
+		pod.Spec.Priority = &priority
+		return pod
+	}
 	pods := []*corev1.Pod{
-		makeDaemonSetPod("kube-system", "fluentbit", "node-1", "250m", "512Mi"),
-		makePodWithResources("default", "app", "node-1", corev1.PodRunning,
-			[]corev1.Container{makeContainer("app", "1", "2Gi")}, nil),
+		withPriority(makePodWithResources("default", "api", "node-1", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "2", "")}, nil), "critical", 1000000),
+		withPriority(makePodWithResources("default", "placeholder", "node-1", corev1.PodRunning,
+			[]corev1.Container{makeContainer("pause", "1500m", "")}, nil), "overprovisioning", -10),
+		makePodWithResources("default", "web", "node-2", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "2", "")}, nil),
+		withPriority(makePodWithResources("default", "batch", "node-2", corev1.PodRunning,
+			[]corev1.Container{makeContainer("app", "3", "")}, nil), "critical", 1000000),
 	}
 
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
-	resources := []corev1.ResourceName{corev1.ResourceCPU}
 	collector := NewBinpackingCollector(
 		&fakeNodeLister{nodes: nodes}, &fakePodLister{pods: pods},
-		logger, resources, nil, false, nil, nil, // enableNodeMetrics = false
+		logger, []corev1.ResourceName{corev1.ResourceCPU}, [][]string{{"pool"}}, true, nil, nil,
+		CollectorOptions{EnablePriorityClassMetrics: true, PriorityThresholds: []int32{0, 1000000}},
 	)
 
-	ch := make(chan prometheus.Metric, 100)
-	collector.Collect(ch)
-	close(ch)
-
-	var nodeDSCount, clusterDSCount int
-	for m := range ch {
-		desc := m.Desc().String()
-		switch {
-		case contains(desc, "kube_binpacking_node_daemonset"):
-			nodeDSCount++
-		case contains(desc, "kube_binpacking_cluster_daemonset"):
-			clusterDSCount++
-		}
-	}
-
-	if nodeDSCount > 0 {
-		t.Errorf("Expected 0 node DS metrics when disabled, got %d", nodeDSCount)
-	}
-	// 1 resource × 2 cluster DS metrics (overhead + ratio)
-	if clusterDSCount != 2 {
-		t.Errorf("Expected 2 cluster DS metrics, got %d", clusterDSCount)
+	// Effective free for priority >= 0: node-1 4-2 = 2 (the placeholder is
+	// preemptible), node-2 max(4-2-3, 0) = 0. For >= 1000000: node-1 2, node-2 4-3 =
+	// 1 (the web pod without a PriorityClass has priority 0).
+	expected := `
+# HELP kube_binpacking_node_priority_class_allocated Total resource requested by pods on this node by PriorityClass
+# TYPE kube_binpacking_node_priority_class_allocated gauge
+kube_binpacking_node_priority_class_allocated{node="node-1",priority_class="critical",resource="cpu"} 2
+kube_binpacking_node_priority_class_allocated{node="node-1",priority_class="overprovisioning",resource="cpu"} 1.5
+kube_binpacking_node_priority_class_allocated{node="node-2",priority_class="<none>",resource="cpu"} 2
+kube_binpacking_node_priority_class_allocated{node="node-2",priority_class="critical",resource="cpu"} 3
+# HELP kube_binpacking_cluster_priority_class_allocated Cluster-wide total resource requested by pods by PriorityClass
+# TYPE kube_binpacking_cluster_priority_class_allocated gauge
+kube_binpacking_cluster_priority_class_allocated{priority_class="<none>",resource="cpu"} 2
+kube_binpacking_cluster_priority_class_allocated{priority_class="critical",resource="cpu"} 5
+kube_binpacking_cluster_priority_class_allocated{priority_class="overprovisioning",resource="cpu"} 1.5
+# HELP kube_binpacking_cluster_priority_effective_free Cluster-wide allocatable resource free for pods of at least this priority, counting requests of lower-priority pods as preemptible
+# TYPE kube_binpacking_cluster_priority_effective_free gauge
+kube_binpacking_cluster_priority_effective_free{min_priority="0",resource="cpu"} 2
+kube_binpacking_cluster_priority_effective_free{min_priority="1000000",resource="cpu"} 3
+# HELP kube_binpacking_group_priority_class_allocated Total resource requested by pods on nodes in this label group by PriorityClass
+# TYPE kube_binpacking_group_priority_class_allocated gauge
+kube_binpacking_group_priority_class_allocated{label_group="pool",label_group_value="apps",priority_class="<none>",resource="cpu"} 2
+kube_binpacking_group_priority_class_allocated{label_group="pool",label_group_value="apps",priority_class="critical",resource="cpu"} 5
+kube_binpacking_group_priority_class_allocated{label_group="pool",label_group_value="apps",priority_class="overprovisioning",resource="cpu"} 1.5
+# HELP kube_binpacking_group_priority_effective_free Allocatable resource free for pods of at least this priority on nodes in this label group, counting requests of lower-priority pods as preemptible
+# TYPE kube_binpacking_group_priority_effective_free gauge
+kube_binpacking_group_priority_effective_free{label_group="pool",label_group_value="apps",min_priority="0",resource="cpu"} 2
+kube_binpacking_group_priority_effective_free{label_group="pool",label_group_value="apps",min_priority="1000000",resource="cpu"} 3
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"kube_binpacking_node_priority_class_allocated",
+		"kube_binpacking_cluster_priority_class_allocated",
+		"kube_binpacking_cluster_priority_effective_free",
+		"kube_binpacking_group_priority_class_allocated",
+		"kube_binpacking_group_priority_effective_free",
+	); err != nil {
+		t.Error(err)
 	}
 }
@@ -29,7 +29,7 @@ type SyncInfo struct {
 	PodSynced    func() bool
 }
 
-func setupKubernetes(ctx context.Context, logger *slog.Logger, kubeconfigPath string, resyncPeriod time.Duration, listPageSize int64, nodeSelector string) (listerscorev1.NodeLister, listerscorev1.PodLister, ReadyChecker, *SyncInfo, kubernetes.Interface, error) {
+func setupKubernetes(ctx context.Context, logger *slog.Logger, kubeconfigPath string, resyncPeriod time.Duration, listPageSize int64, nodeSelector string, podLabelKeys []string) (listerscorev1.NodeLister, listerscorev1.PodLister, ReadyChecker, *SyncInfo, kubernetes.Interface, error) {
 	config, configSource, err := buildConfig(kubeconfigPath)
 	if err != nil {
 		return nil, nil, nil, nil, nil, fmt.Errorf("building kubeconfig: %w", err)
@@ -61,10 +61,10 @@ func setupKubernetes(ctx context.Context, logger *slog.Logger, kubeconfigPath st
 	// allocation calculations. This requires a separate factory because
 	// WithTweakListOptions applies to all informers in a factory.
 	nodeOpts := []informers.SharedInformerOption{
-		informers.WithTransform(stripUnusedFields),
+		informers.WithTransform(stripUnusedFields(podLabelKeys)),
 	}
 	podOpts := []informers.SharedInformerOption{
-		informers.WithTransform(stripUnusedFields),
+		informers.WithTransform(stripUnusedFields(podLabelKeys)),
 		informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
 			opts.FieldSelector = "status.phase!=Succeeded,status.phase!=Failed"
 			if listPageSize > 0 {
@@ -203,52 +203,156 @@ func buildConfig(kubeconfigPath string) (*rest.Config, string, error) {
 	return cfg, "in-cluster", err
 }
 
-// stripUnusedFields is a cache.TransformFunc that removes fields from Pod and
-// Node objects before they enter the informer cache. This exporter only needs
-// a handful of fields per object; stripping the rest reduces memory by ~90%
-// in clusters with many pods.
-func stripUnusedFields(obj interface{}) (interface{}, error) {
-	switch v := obj.(type) {
-	case *corev1.Pod:
-		// Keep only: Name, Namespace, NodeName, Phase, container resource requests
-		containers := make([]corev1.Container, len(v.Spec.Containers))
-		for i, c := range v.Spec.Containers {
-			containers[i] = corev1.Container{
-				Name:      c.Name,
-				Resources: corev1.ResourceRequirements{Requests: c.Resources.Requests},
+// stripUnusedFields returns a cache.TransformFunc that removes fields from
+// Pod and Node objects before they enter the informer cache. This exporter
+// only needs a handful of fields per object; stripping the rest reduces memory
+// by ~90% in clusters with many pods. Pod labels are dropped except for
+// podLabelKeys, the keys overhead class selectors match on.
+func stripUnusedFields(podLabelKeys []string) cache.TransformFunc {
+	return func(obj interface{}) (interface{}, error) {
+		switch v := obj.(type) {
+		case *corev1.Pod:
+			// Keep only: Name, Namespace, the labels in podLabelKeys,
+			// CreationTimestamp, DeletionTimestamp, the mirror pod annotation
+			// (static pods), NodeName, priority and PriorityClass, Phase, QoS
+			// class, container resource requests and limits, init
+			// container restart policy (sidecars), RuntimeClass overhead,
+			// pod-level resources, in-place resize status
+			containers := make([]corev1.Container, len(v.Spec.Containers))
+			for i, c := range v.Spec.Containers {
+				containers[i] = corev1.Container{
+					Name:      c.Name,
+					Resources: corev1.ResourceRequirements{Requests: c.Resources.Requests, Limits: c.Resources.Limits},
+				}
 			}
-		}
-		initContainers := make([]corev1.Container, len(v.Spec.InitContainers))
-		for i, c := range v.Spec.InitContainers {
-			initContainers[i] = corev1.Container{
-				Name:      c.Name,
-				Resources: corev1.ResourceRequirements{Requests: c.Resources.Requests},
+			initContainers := make([]corev1.Container, len(v.Spec.InitContainers))
+			for i, c := range v.Spec.InitContainers {
+				initContainers[i] = corev1.Container{
+					Name:          c.Name,
+					Resources:     corev1.ResourceRequirements{Requests: c.Resources.Requests, Limits: c.Resources.Limits},
+					RestartPolicy: c.RestartPolicy,
+				}
+			}
+			var podResources *corev1.ResourceRequirements
+			if v.Spec.Resources != nil {
+				podResources = &corev1.ResourceRequirements{Requests: v.Spec.Resources.Requests, Limits: v.Spec.Resources.Limits}
+			}
+			v.Spec = corev1.PodSpec{
+				NodeName:          v.Spec.NodeName,
+				Containers:        containers,
+				InitContainers:    initContainers,
+				Overhead:          v.Spec.Overhead,
+				Resources:         podResources,
+				Priority:          v.Spec.Priority,
+				PriorityClassName: v.Spec.PriorityClassName,
+			}
+			v.Status = corev1.PodStatus{
+				Phase:                 v.Status.Phase,
+				QOSClass:              v.Status.QOSClass,
+				Conditions:            resizeConditions(v.Status.Conditions),
+				ContainerStatuses:     resizeContainerStatuses(v.Status.ContainerStatuses),
+				InitContainerStatuses: resizeContainerStatuses(v.Status.InitContainerStatuses),
+			}
+			v.ObjectMeta = metav1.ObjectMeta{
+				Name:              v.Name,
+				Namespace:         v.Namespace,
+				Labels:            selectLabels(v.Labels, podLabelKeys),
+				CreationTimestamp: v.CreationTimestamp,
+				DeletionTimestamp: v.DeletionTimestamp,
+				OwnerReferences:   v.OwnerReferences,
+				Annotations:       mirrorAnnotation(v.Annotations),
+			}
+			return v, nil
+
+		case *corev1.Node:
+			// Keep only: Name, Labels, DeletionTimestamp, Allocatable, Capacity,
+			// Unschedulable, taints and the Ready condition (node state)
+			v.ObjectMeta = metav1.ObjectMeta{
+				Name:              v.Name,
+				Labels:            v.Labels,
+				DeletionTimestamp: v.DeletionTimestamp,
 			}
+			v.Status = corev1.NodeStatus{
+				Allocatable: v.Status.Allocatable,
+				Capacity:    v.Status.Capacity,
+				Conditions:  readyCondition(v.Status.Conditions),
+			}
+			v.Spec = corev1.NodeSpec{
+				Unschedulable: v.Spec.Unschedulable,
+				Taints:        v.Spec.Taints,
+			}
+			return v, nil
+
+		default:
+			return obj, nil
 		}
-		v.Spec = corev1.PodSpec{
-			NodeName:       v.Spec.NodeName,
-			Containers:     containers,
-			InitContainers: initContainers,
+	}
+}
+
+// selectLabels keeps only the labels with the given keys.
+func selectLabels(all map[string]string, keys []string) map[string]string {
+	var kept map[string]string
+	for _, k := range keys {
+		if v, ok := all[k]; ok {
+			if kept == nil {
+				kept = make(map[string]string, len(keys))
+			}
+			kept[k] = v
 		}
-		v.Status = corev1.PodStatus{Phase: v.Status.Phase}
-		v.ObjectMeta = metav1.ObjectMeta{
-			Name:            v.Name,
-			Namespace:       v.Namespace,
-			OwnerReferences: v.OwnerReferences,
+	}
+	return kept
+}
+
+// mirrorAnnotation keeps only the mirror pod annotation, which marks static
+// pods.
+func mirrorAnnotation(annotations map[string]string) map[string]string {
+	if v, ok := annotations[corev1.MirrorPodAnnotationKey]; ok {
+		return map[string]string{corev1.MirrorPodAnnotationKey: v}
+	}
+	return nil
+}
+
+// readyCondition keeps only the node Ready condition.
+func readyCondition(conditions []corev1.NodeCondition) []corev1.NodeCondition {
+	for _, cond := range conditions {
+		if cond.Type == corev1.NodeReady {
+			return []corev1.NodeCondition{{Type: cond.Type, Status: cond.Status}}
 		}
-		return v, nil
+	}
+	return nil
+}
 
-	case *corev1.Node:
-		// Keep only: Name, Labels, Allocatable
-		v.ObjectMeta = metav1.ObjectMeta{
-			Name:   v.Name,
-			Labels: v.Labels,
+// resizeConditions keeps only the in-place pod resize conditions.
+func resizeConditions(conditions []corev1.PodCondition) []corev1.PodCondition {
+	var kept []corev1.PodCondition
+	for _, cond := range conditions {
+		if cond.Type == corev1.PodResizePending || cond.Type == corev1.PodResizeInProgress {
+			kept = append(kept, corev1.PodCondition{
+				Type:   cond.Type,
+				Status: cond.Status,
+				Reason: cond.Reason,
+			})
 		}
-		v.Status = corev1.NodeStatus{Allocatable: v.Status.Allocatable}
-		v.Spec = corev1.NodeSpec{}
-		return v, nil
+	}
+	return kept
+}
 
-	default:
-		return obj, nil
+// resizeContainerStatuses keeps only the container statuses that report
+// actuated or allocated resources, stripped down to those fields.
+func resizeContainerStatuses(statuses []corev1.ContainerStatus) []corev1.ContainerStatus {
+	var kept []corev1.ContainerStatus
+	for _, cs := range statuses {
+		if cs.Resources == nil && cs.AllocatedResources == nil {
+			continue
+		}
+		stripped := corev1.ContainerStatus{
+			Name:               cs.Name,
+			AllocatedResources: cs.AllocatedResources,
+		}
+		if cs.Resources != nil {
+			stripped.Resources = &corev1.ResourceRequirements{Requests: cs.Resources.Requests, Limits: cs.Resources.Limits}
+		}
+		kept = append(kept, stripped)
 	}
+	return kept
 }
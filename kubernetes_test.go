@@ -99,12 +99,16 @@ func TestReadinessCheck(t *testing.T) {
 // fields used by the collector: Name, Namespace, NodeName, Phase, and
 // container resource requests. Everything else should be zeroed.
 func TestStripUnusedFields_Pod(t *testing.T) {
+	sidecarRestartPolicy := corev1.ContainerRestartPolicyAlways
+	priority := int32(1000)
 	pod := &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "my-pod",
-			Namespace: "default",
-			UID:       "abc-123",
-			Labels:    map[string]string{"app": "web"},
+			Name:              "my-pod",
+			Namespace:         "default",
+			UID:               "abc-123",
+			CreationTimestamp: metav1.NewTime(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)),
+			DeletionTimestamp: &metav1.Time{Time: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)},
+			Labels:            map[string]string{"app": "web"},
 			Annotations: map[string]string{
 				"kubectl.kubernetes.io/last-applied-configuration": `{"big":"json"}`,
 			},
@@ -116,7 +120,9 @@ func TestStripUnusedFields_Pod(t *testing.T) {
 			},
 		},
 		Spec: corev1.PodSpec{
-			NodeName: "node-1",
+			NodeName:          "node-1",
+			Priority:          &priority,
+			PriorityClassName: "high",
 			Containers: []corev1.Container{
 				{
 					Name:  "app",
@@ -148,16 +154,26 @@ func TestStripUnusedFields_Pod(t *testing.T) {
 							corev1.ResourceCPU: resource.MustParse("200m"),
 						},
 					},
-					Command: []string{"sh", "-c", "echo hello"},
+					Command:       []string{"sh", "-c", "echo hello"},
+					RestartPolicy: &sidecarRestartPolicy,
 				},
 			},
 			Volumes: []corev1.Volume{
 				{Name: "data"},
 			},
 			ServiceAccountName: "default",
+			Overhead: corev1.ResourceList{
+				corev1.ResourceCPU: resource.MustParse("250m"),
+			},
+			Resources: &corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU: resource.MustParse("1"),
+				},
+			},
 		},
 		Status: corev1.PodStatus{
-			Phase: corev1.PodRunning,
+			Phase:    corev1.PodRunning,
+			QOSClass: corev1.PodQOSBurstable,
 			Conditions: []corev1.PodCondition{
 				{Type: corev1.PodReady, Status: corev1.ConditionTrue},
 			},
@@ -168,7 +184,7 @@ func TestStripUnusedFields_Pod(t *testing.T) {
 		},
 	}
 
-	result, err := stripUnusedFields(pod)
+	result, err := stripUnusedFields(nil)(pod)
 	if err != nil {
 		t.Fatalf("stripUnusedFields() error = %v", err)
 	}
@@ -185,9 +201,15 @@ func TestStripUnusedFields_Pod(t *testing.T) {
 	if stripped.Spec.NodeName != "node-1" {
 		t.Errorf("NodeName = %q, want %q", stripped.Spec.NodeName, "node-1")
 	}
+	if stripped.Spec.Priority == nil || *stripped.Spec.Priority != priority || stripped.Spec.PriorityClassName != "high" {
+		t.Errorf("Priority = %v %q, want %d %q", stripped.Spec.Priority, stripped.Spec.PriorityClassName, priority, "high")
+	}
 	if stripped.Status.Phase != corev1.PodRunning {
 		t.Errorf("Phase = %v, want %v", stripped.Status.Phase, corev1.PodRunning)
 	}
+	if stripped.Status.QOSClass != corev1.PodQOSBurstable {
+		t.Errorf("QOSClass = %v, want %v", stripped.Status.QOSClass, corev1.PodQOSBurstable)
+	}
 
 	// Container names + requests preserved
 	if len(stripped.Spec.Containers) != 1 {
@@ -208,6 +230,35 @@ func TestStripUnusedFields_Pod(t *testing.T) {
 		t.Errorf("InitContainer name = %q, want %q", stripped.Spec.InitContainers[0].Name, "init")
 	}
 
+	// Sidecar restart policy preserved
+	if stripped.Spec.InitContainers[0].RestartPolicy == nil ||
+		*stripped.Spec.InitContainers[0].RestartPolicy != corev1.ContainerRestartPolicyAlways {
+		t.Errorf("InitContainer RestartPolicy = %v, want Always", stripped.Spec.InitContainers[0].RestartPolicy)
+	}
+
+	// RuntimeClass overhead preserved
+	if _, ok := stripped.Spec.Overhead[corev1.ResourceCPU]; !ok {
+		t.Error("Overhead CPU missing")
+	}
+
+	// Pod-level requests preserved
+	if stripped.Spec.Resources == nil {
+		t.Fatal("Pod-level Resources missing")
+	}
+	if _, ok := stripped.Spec.Resources.Requests[corev1.ResourceCPU]; !ok {
+		t.Error("Pod-level CPU request missing")
+	}
+
+	// Creation timestamp preserved (pending pod age)
+	if stripped.CreationTimestamp.IsZero() {
+		t.Error("CreationTimestamp missing")
+	}
+
+	// Deletion timestamp preserved (terminating pods)
+	if stripped.DeletionTimestamp == nil {
+		t.Error("DeletionTimestamp missing")
+	}
+
 	// Stripped fields — ObjectMeta
 	if stripped.UID != "" {
 		t.Errorf("UID should be empty, got %q", stripped.UID)
@@ -238,8 +289,8 @@ func TestStripUnusedFields_Pod(t *testing.T) {
 	if stripped.Spec.Containers[0].VolumeMounts != nil {
 		t.Errorf("Container VolumeMounts should be nil, got %v", stripped.Spec.Containers[0].VolumeMounts)
 	}
-	if stripped.Spec.Containers[0].Resources.Limits != nil {
-		t.Errorf("Container Limits should be nil, got %v", stripped.Spec.Containers[0].Resources.Limits)
+	if _, ok := stripped.Spec.Containers[0].Resources.Limits[corev1.ResourceMemory]; !ok {
+		t.Error("Container memory limit missing")
 	}
 	if stripped.Spec.InitContainers[0].Image != "" {
 		t.Errorf("InitContainer Image should be empty, got %q", stripped.Spec.InitContainers[0].Image)
@@ -277,15 +328,17 @@ func TestStripUnusedFields_Node(t *testing.T) {
 				"node.kubernetes.io/instance-type":   "m5.large",
 				"kubernetes.io/os":                   "linux",
 			},
-			UID:         "node-uid-123",
-			Annotations: map[string]string{"annotation": "value"},
+			UID:               "node-uid-123",
+			Annotations:       map[string]string{"annotation": "value"},
+			DeletionTimestamp: &metav1.Time{Time: time.Now()},
 			ManagedFields: []metav1.ManagedFieldsEntry{
 				{Manager: "kubelet"},
 			},
 		},
 		Spec: corev1.NodeSpec{
-			PodCIDR:    "10.0.0.0/24",
-			ProviderID: "aws:///us-east-1a/i-abc123",
+			PodCIDR:       "10.0.0.0/24",
+			ProviderID:    "aws:///us-east-1a/i-abc123",
+			Unschedulable: true,
 			Taints: []corev1.Taint{
 				{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule},
 			},
@@ -301,6 +354,7 @@ func TestStripUnusedFields_Node(t *testing.T) {
 			},
 			Conditions: []corev1.NodeCondition{
 				{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+				{Type: corev1.NodeMemoryPressure, Status: corev1.ConditionFalse},
 			},
 			Addresses: []corev1.NodeAddress{
 				{Type: corev1.NodeInternalIP, Address: "192.168.1.1"},
@@ -316,7 +370,7 @@ func TestStripUnusedFields_Node(t *testing.T) {
 		},
 	}
 
-	result, err := stripUnusedFields(node)
+	result, err := stripUnusedFields(nil)(node)
 	if err != nil {
 		t.Fatalf("stripUnusedFields() error = %v", err)
 	}
@@ -339,6 +393,21 @@ func TestStripUnusedFields_Node(t *testing.T) {
 	if _, ok := stripped.Status.Allocatable[corev1.ResourceMemory]; !ok {
 		t.Error("Allocatable Memory missing")
 	}
+	if _, ok := stripped.Status.Capacity[corev1.ResourceCPU]; !ok {
+		t.Error("Capacity CPU missing")
+	}
+	if !stripped.Spec.Unschedulable {
+		t.Error("Unschedulable should be preserved")
+	}
+	if len(stripped.Spec.Taints) != 1 || stripped.Spec.Taints[0].Key != "dedicated" {
+		t.Errorf("Taints = %v, want the dedicated taint", stripped.Spec.Taints)
+	}
+	if stripped.DeletionTimestamp == nil {
+		t.Error("DeletionTimestamp should be preserved")
+	}
+	if len(stripped.Status.Conditions) != 1 || stripped.Status.Conditions[0].Type != corev1.NodeReady {
+		t.Errorf("Conditions = %v, want only Ready", stripped.Status.Conditions)
+	}
 
 	// Stripped fields — ObjectMeta
 	if stripped.UID != "" {
@@ -358,17 +427,8 @@ func TestStripUnusedFields_Node(t *testing.T) {
 	if stripped.Spec.ProviderID != "" {
 		t.Errorf("ProviderID should be empty, got %q", stripped.Spec.ProviderID)
 	}
-	if stripped.Spec.Taints != nil {
-		t.Errorf("Taints should be nil, got %v", stripped.Spec.Taints)
-	}
 
 	// Stripped fields — Status
-	if stripped.Status.Capacity != nil {
-		t.Errorf("Capacity should be nil, got %v", stripped.Status.Capacity)
-	}
-	if stripped.Status.Conditions != nil {
-		t.Errorf("Conditions should be nil, got %v", stripped.Status.Conditions)
-	}
 	if stripped.Status.Addresses != nil {
 		t.Errorf("Addresses should be nil, got %v", stripped.Status.Addresses)
 	}
@@ -413,7 +473,7 @@ func TestStripUnusedFields_PreservesRequests(t *testing.T) {
 		Status: corev1.PodStatus{Phase: corev1.PodRunning},
 	}
 
-	result, err := stripUnusedFields(pod)
+	result, err := stripUnusedFields(nil)(pod)
 	if err != nil {
 		t.Fatalf("stripUnusedFields() error = %v", err)
 	}
@@ -451,6 +511,136 @@ func TestStripUnusedFields_PreservesRequests(t *testing.T) {
 	}
 }
 
+// TestStripUnusedFields_ResizeStatus verifies that the in-place resize fields
+// used by the collector survive the transform: resize conditions and the
+// actuated/allocated resources of container statuses.
+func TestStripUnusedFields_ResizeStatus(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "resize-pod", Namespace: "ns"},
+		Spec: corev1.PodSpec{
+			NodeName: "node-1",
+			Containers: []corev1.Container{
+				{Name: "app", Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+				}},
+			},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			Conditions: []corev1.PodCondition{
+				{Type: corev1.PodReady, Status: corev1.ConditionTrue},
+				{Type: corev1.PodResizePending, Status: corev1.ConditionTrue, Reason: corev1.PodReasonDeferred, Message: "not enough cpu"},
+			},
+			ContainerStatuses: []corev1.ContainerStatus{
+				{
+					Name:  "app",
+					Image: "nginx:latest",
+					Resources: &corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+						Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+					},
+					AllocatedResources: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+				},
+			},
+		},
+	}
+
+	result, err := stripUnusedFields(nil)(pod)
+	if err != nil {
+		t.Fatalf("stripUnusedFields() error = %v", err)
+	}
+	stripped := result.(*corev1.Pod)
+
+	if len(stripped.Status.Conditions) != 1 {
+		t.Fatalf("Conditions count = %d, want 1", len(stripped.Status.Conditions))
+	}
+	cond := stripped.Status.Conditions[0]
+	if cond.Type != corev1.PodResizePending || cond.Reason != corev1.PodReasonDeferred {
+		t.Errorf("Condition = %s/%s, want %s/%s", cond.Type, cond.Reason, corev1.PodResizePending, corev1.PodReasonDeferred)
+	}
+	if cond.Message != "" {
+		t.Errorf("Condition Message should be empty, got %q", cond.Message)
+	}
+
+	if len(stripped.Status.ContainerStatuses) != 1 {
+		t.Fatalf("ContainerStatuses count = %d, want 1", len(stripped.Status.ContainerStatuses))
+	}
+	cs := stripped.Status.ContainerStatuses[0]
+	if cs.Image != "" {
+		t.Errorf("ContainerStatus Image should be empty, got %q", cs.Image)
+	}
+	if cs.Resources == nil {
+		t.Fatal("ContainerStatus Resources missing")
+	}
+	if _, ok := cs.Resources.Requests[corev1.ResourceCPU]; !ok {
+		t.Error("ContainerStatus actuated CPU request missing")
+	}
+	if _, ok := cs.Resources.Limits[corev1.ResourceCPU]; !ok {
+		t.Error("ContainerStatus actuated CPU limit missing")
+	}
+	if _, ok := cs.AllocatedResources[corev1.ResourceCPU]; !ok {
+		t.Error("ContainerStatus allocated CPU missing")
+	}
+
+	// Deferred resize reserves max(desired, actuated) on the transformed pod.
+	effective, _ := calculatePodRequest(stripped, corev1.ResourceCPU)
+	if effective < 1.999 || effective > 2.001 {
+		t.Errorf("calculatePodRequest(CPU) = %f, want ~2", effective)
+	}
+}
+
+// TestStripUnusedFields_MirrorPod verifies that the mirror pod annotation,
+// which marks static pods, survives the transform while other annotations
+// are dropped.
+func TestStripUnusedFields_MirrorPod(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kube-apiserver-node-1",
+			Namespace: "kube-system",
+			Annotations: map[string]string{
+				corev1.MirrorPodAnnotationKey: "abc123",
+				"kubernetes.io/config.source": "file",
+			},
+		},
+	}
+
+	result, err := stripUnusedFields(nil)(pod)
+	if err != nil {
+		t.Fatalf("stripUnusedFields() error = %v", err)
+	}
+
+	stripped := result.(*corev1.Pod)
+	if len(stripped.Annotations) != 1 || stripped.Annotations[corev1.MirrorPodAnnotationKey] != "abc123" {
+		t.Errorf("Annotations = %v, want only the mirror annotation", stripped.Annotations)
+	}
+}
+
+// TestStripUnusedFields_PodLabelKeys verifies that only the pod labels
+// overhead class selectors match on survive the transform.
+func TestStripUnusedFields_PodLabelKeys(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "agent",
+			Namespace: "monitoring",
+			Labels: map[string]string{
+				"platform":          "true",
+				"app":               "agent",
+				"pod-template-hash": "5d8f9c",
+			},
+		},
+	}
+
+	result, err := stripUnusedFields([]string{"platform", "tier"})(pod)
+	if err != nil {
+		t.Fatalf("stripUnusedFields() error = %v", err)
+	}
+
+	stripped := result.(*corev1.Pod)
+	if len(stripped.Labels) != 1 || stripped.Labels["platform"] != "true" {
+		t.Errorf("Labels = %v, want only platform=true", stripped.Labels)
+	}
+}
+
 // TestStripUnusedFields_UnknownType verifies that non-Pod/Node objects pass
 // through unchanged.
 func TestStripUnusedFields_UnknownType(t *testing.T) {
@@ -464,7 +654,7 @@ func TestStripUnusedFields_UnknownType(t *testing.T) {
 		},
 	}
 
-	result, err := stripUnusedFields(svc)
+	result, err := stripUnusedFields(nil)(svc)
 	if err != nil {
 		t.Fatalf("stripUnusedFields() error = %v", err)
 	}
@@ -8,6 +8,9 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"path"
+	"slices"
+	"strconv"
 	"strings"
 	"sync/atomic"
 	"syscall"
@@ -34,17 +37,34 @@ func (s *stringSliceFlag) Set(val string) error {
 
 func main() {
 	var (
-		kubeconfig      string
-		metricsAddr     string
-		metricsPath     string
-		resourceCSV     string
-		labelGroupFlags stringSliceFlag
-		logLevel        string
-		logFormat       string
-		resyncPeriod    string
-		listPageSize       int
-		nodeSelector       string
-		disableNodeMetrics bool
+		kubeconfig             string
+		metricsAddr            string
+		metricsPath            string
+		resourceCSV            string
+		labelGroupFlags        stringSliceFlag
+		aliasFlags             stringSliceFlag
+		overheadClassFlags     stringSliceFlag
+		logLevel               string
+		logFormat              string
+		resyncPeriod           string
+		listPageSize           int
+		nodeSelector           string
+		disableNodeMetrics     bool
+		enableLimitsMetrics    bool
+		pendingByNamespace     bool
+		strandedThreshold      float64
+		histogramMode          string
+		consolidationThreshold float64
+		consolidationMode      string
+		simulateConsolidation  bool
+		nodeStateMode          string
+		excludeTerminating     bool
+		namespaceAllocation    bool
+		workloadKindMetrics    bool
+		qosMetrics             bool
+		priorityClassMetrics   bool
+		priorityThresholdCSV   string
+		namespaceTopN          int
 
 		leaderElect              bool
 		leaderElectLeaseName     string
@@ -58,9 +78,26 @@ func main() {
 	flag.StringVar(&kubeconfig, "kubeconfig", "", "path to kubeconfig (uses in-cluster config if empty)")
 	flag.StringVar(&metricsAddr, "metrics-addr", ":9101", "address to serve metrics on")
 	flag.StringVar(&metricsPath, "metrics-path", "/metrics", "HTTP path for metrics endpoint")
-	flag.StringVar(&resourceCSV, "resources", "cpu,memory", "comma-separated list of resources to track")
+	flag.StringVar(&resourceCSV, "resources", "cpu,memory", "comma-separated list of resources to track; supports glob patterns (e.g., hugepages-*, nvidia.com/*) and auto (every resource in any node's allocatable)")
 	flag.Var(&labelGroupFlags, "label-group", "comma-separated label keys defining one combination group (repeatable, e.g., --label-group=zone,instance-type --label-group=zone)")
+	flag.Var(&aliasFlags, "resource-alias", "virtual resource summing weighted source resources, as name=resource[:weight]+... (repeatable, e.g., --resource-alias=gpu=nvidia.com/gpu+amd.com/gpu+nvidia.com/mig-1g.10gb:0.14)")
+	flag.Var(&overheadClassFlags, "overhead-class", "named overhead class reported like DaemonSet overhead, as name:namespaces=ns1,ns2 or name:selector=<label selector> (repeatable; repeating a name adds alternatives, e.g., --overhead-class=platform:namespaces=kube-system,monitoring --overhead-class=platform:selector=platform=true)")
 	flag.BoolVar(&disableNodeMetrics, "disable-node-metrics", false, "disable per-node metrics to reduce cardinality (only emit cluster-wide and group metrics)")
+	flag.BoolVar(&enableLimitsMetrics, "enable-limits-metrics", false, "emit resource limits, overcommit ratio and pods-without-limits metrics alongside requests")
+	flag.BoolVar(&pendingByNamespace, "enable-pending-by-namespace", false, "break pending pod metrics down by namespace (adds a namespace label, increases cardinality)")
+	flag.Float64Var(&strandedThreshold, "stranded-threshold", defaultStrandedThreshold, "utilization ratio (0-1] at which a resource is saturated, stranding the free amount of the other tracked resources on the node")
+	flag.StringVar(&histogramMode, "utilization-histogram", "none", "emit node utilization distributions per label group and cluster-wide: none, classic, native")
+	flag.Float64Var(&consolidationThreshold, "consolidation-threshold", 0, "utilization ratio [0-1] below which a node is a consolidation candidate (e.g., 0.5; 0 = disabled)")
+	flag.StringVar(&consolidationMode, "consolidation-mode", string(ConsolidationDominant), "utilization compared with --consolidation-threshold: dominant (highest ratio across tracked resources), per-resource")
+	flag.BoolVar(&simulateConsolidation, "enable-consolidation-simulation", false, "simulate repacking each label group's pods to estimate the nodes it requires (lower bound and first-fit-decreasing)")
+	flag.StringVar(&nodeStateMode, "node-state-mode", string(NodeStateInclude), "how cordoned, not ready and deleting nodes are aggregated: include, exclude (leave them out of cluster and group metrics), separate (exclude and report per node_state)")
+	flag.BoolVar(&excludeTerminating, "exclude-terminating-pods", false, "leave pods with a deletion timestamp out of allocated and utilization (still reported as terminating_allocated)")
+	flag.BoolVar(&workloadKindMetrics, "enable-workload-kind-metrics", false, "break allocated down by owning workload kind (daemonset, replicaset, statefulset, job, static, bare, other)")
+	flag.BoolVar(&qosMetrics, "enable-qos-metrics", false, "break allocated and pod counts down by pod QoS class (guaranteed, burstable, best_effort)")
+	flag.BoolVar(&priorityClassMetrics, "enable-priority-class-metrics", false, "break allocated down by pod PriorityClass")
+	flag.StringVar(&priorityThresholdCSV, "priority-thresholds", "", "comma-separated pod priorities for which to report the free allocatable if all lower-priority pods were preempted (e.g., 0,1000000)")
+	flag.BoolVar(&namespaceAllocation, "enable-namespace-allocation", false, "break allocated down by namespace per label group and cluster-wide (adds a namespace label, increases cardinality)")
+	flag.IntVar(&namespaceTopN, "namespace-top-n", 10, "keep only the N namespaces with the largest allocation per resource, summing the rest as <other> (0 = all namespaces)")
 	flag.StringVar(&logLevel, "log-level", "info", "log level: debug, info, warn, error")
 	flag.StringVar(&logFormat, "log-format", "json", "log format: json, text")
 	flag.StringVar(&resyncPeriod, "resync-period", "30m", "informer cache resync period (e.g., 1m, 30s, 1h30m)")
@@ -81,8 +118,36 @@ func main() {
 	logger.Info("starting kube-binpacking-exporter", "version", version, "log_level", logLevel, "log_format", logFormat)
 
 	resources := parseResources(resourceCSV)
+	if err := validateResources(resources); err != nil {
+		logger.Error("invalid resources", "error", err, "value", resourceCSV)
+		os.Exit(1)
+	}
 	logger.Info("tracking resources", "resources", resourceCSV)
 
+	aliases, err := parseResourceAliases(aliasFlags, resources)
+	if err != nil {
+		logger.Error("invalid resource alias", "error", err)
+		os.Exit(1)
+	}
+	if len(aliases) > 0 {
+		logger.Info("tracking resource aliases", "aliases", []string(aliasFlags))
+	}
+
+	overheadClasses, err := parseOverheadClasses(overheadClassFlags)
+	if err != nil {
+		logger.Error("invalid overhead class", "error", err)
+		os.Exit(1)
+	}
+	if len(overheadClasses) > 0 {
+		logger.Info("tracking overhead classes", "classes", []string(overheadClassFlags))
+	}
+
+	priorityThresholds, err := parsePriorityThresholds(priorityThresholdCSV)
+	if err != nil {
+		logger.Error("invalid priority thresholds", "error", err, "value", priorityThresholdCSV)
+		os.Exit(1)
+	}
+
 	labelGroups := parseLabelGroups(labelGroupFlags)
 	if len(labelGroups) > 0 {
 		groupStrs := make([]string, len(labelGroups))
@@ -96,6 +161,43 @@ func main() {
 		logger.Info("per-node metrics disabled - only emitting cluster-wide and group metrics")
 	}
 
+	if strandedThreshold <= 0 || strandedThreshold > 1 {
+		logger.Error("invalid stranded threshold, must be in (0, 1]", "value", strandedThreshold)
+		os.Exit(1)
+	}
+
+	utilizationHistogram, err := parseHistogramMode(histogramMode)
+	if err != nil {
+		logger.Error("invalid utilization histogram mode", "error", err, "value", histogramMode)
+		os.Exit(1)
+	}
+
+	if consolidationThreshold < 0 || consolidationThreshold > 1 {
+		logger.Error("invalid consolidation threshold, must be in [0, 1]", "value", consolidationThreshold)
+		os.Exit(1)
+	}
+
+	consolidation, err := parseConsolidationMode(consolidationMode)
+	if err != nil {
+		logger.Error("invalid consolidation mode", "error", err, "value", consolidationMode)
+		os.Exit(1)
+	}
+
+	nodeStates, err := parseNodeStateMode(nodeStateMode)
+	if err != nil {
+		logger.Error("invalid node state mode", "error", err, "value", nodeStateMode)
+		os.Exit(1)
+	}
+
+	if namespaceTopN < 0 {
+		logger.Error("invalid namespace top N, must be >= 0", "value", namespaceTopN)
+		os.Exit(1)
+	}
+
+	if enableLimitsMetrics {
+		logger.Info("limits and overcommit metrics enabled")
+	}
+
 	resync, err := time.ParseDuration(resyncPeriod)
 	if err != nil {
 		logger.Error("invalid resync period", "error", err, "value", resyncPeriod)
@@ -114,7 +216,7 @@ func main() {
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
-	nodeLister, podLister, readyChecker, syncInfo, clientset, err := setupKubernetes(ctx, logger, kubeconfig, resync, int64(listPageSize), nodeSelector)
+	nodeLister, podLister, readyChecker, syncInfo, clientset, err := setupKubernetes(ctx, logger, kubeconfig, resync, int64(listPageSize), nodeSelector, overheadLabelKeys(overheadClasses))
 	if err != nil {
 		logger.Error("failed to setup kubernetes client", "error", err)
 		os.Exit(1)
@@ -165,7 +267,25 @@ func main() {
 		go runLeaderElection(ctx, clientset, leConfig, isLeader, logger)
 	}
 
-	collector := NewBinpackingCollector(nodeLister, podLister, logger, resources, labelGroups, !disableNodeMetrics, syncInfo, isLeader)
+	collector := NewBinpackingCollector(nodeLister, podLister, logger, resources, labelGroups, !disableNodeMetrics, syncInfo, isLeader, CollectorOptions{
+		EnableLimitsMetrics:        enableLimitsMetrics,
+		ResourceAliases:            aliases,
+		EnablePendingByNamespace:   pendingByNamespace,
+		StrandedThreshold:          strandedThreshold,
+		UtilizationHistogram:       utilizationHistogram,
+		ConsolidationThreshold:     consolidationThreshold,
+		ConsolidationMode:          consolidation,
+		SimulateConsolidation:      simulateConsolidation,
+		NodeStateMode:              nodeStates,
+		ExcludeTerminatingPods:     excludeTerminating,
+		EnableNamespaceAllocation:  namespaceAllocation,
+		EnableWorkloadKindMetrics:  workloadKindMetrics,
+		EnableQOSMetrics:           qosMetrics,
+		EnablePriorityClassMetrics: priorityClassMetrics,
+		PriorityThresholds:         priorityThresholds,
+		NamespaceTopN:              namespaceTopN,
+		OverheadClasses:            overheadClasses,
+	})
 	registry := prometheus.NewRegistry()
 	registry.MustRegister(collector)
 
@@ -310,6 +430,217 @@ func parseResources(csv string) []corev1.ResourceName {
 	return resources
 }
 
+// validateResources checks that every resource pattern is a valid glob.
+func validateResources(resources []corev1.ResourceName) error {
+	for _, res := range resources {
+		if _, err := path.Match(string(res), ""); err != nil {
+			return fmt.Errorf("invalid resource pattern %q: %w", res, err)
+		}
+	}
+	return nil
+}
+
+// parseResourceAliases parses --resource-alias flags of the form
+// name=resource[:weight]+resource[:weight]... Weights default to 1. Sources
+// must be exact resource names, not patterns or other aliases, and names
+// must not shadow an exact entry of resources.
+func parseResourceAliases(flags []string, resources []corev1.ResourceName) ([]ResourceAlias, error) {
+	var aliases []ResourceAlias
+	seen := make(map[corev1.ResourceName]bool)
+	for _, f := range flags {
+		name, sources, ok := strings.Cut(f, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("alias %q: expected name=resource[:weight]+", f)
+		}
+		alias := ResourceAlias{Name: corev1.ResourceName(name)}
+		if isResourcePattern(alias.Name) {
+			return nil, fmt.Errorf("alias %q: name must not be a pattern", f)
+		}
+		if seen[alias.Name] {
+			return nil, fmt.Errorf("alias %q: duplicate name %q", f, name)
+		}
+		if slices.Contains(resources, alias.Name) {
+			return nil, fmt.Errorf("alias %q: name %q is already a tracked resource", f, name)
+		}
+		seen[alias.Name] = true
+
+		for _, s := range strings.Split(sources, "+") {
+			res, weightStr, hasWeight := strings.Cut(strings.TrimSpace(s), ":")
+			if res == "" {
+				return nil, fmt.Errorf("alias %q: empty source resource", f)
+			}
+			if isResourcePattern(corev1.ResourceName(res)) {
+				return nil, fmt.Errorf("alias %q: source %q must not be a pattern", f, res)
+			}
+			weight := 1.0
+			if hasWeight {
+				w, err := strconv.ParseFloat(weightStr, 64)
+				if err != nil || w <= 0 {
+					return nil, fmt.Errorf("alias %q: invalid weight %q for %s", f, weightStr, res)
+				}
+				weight = w
+			}
+			alias.Sources = append(alias.Sources, AliasSource{Resource: corev1.ResourceName(res), Weight: weight})
+		}
+		aliases = append(aliases, alias)
+	}
+
+	// Aliases are resolved one level deep, so an alias of an alias would
+	// always report zero.
+	for _, alias := range aliases {
+		for _, src := range alias.Sources {
+			if seen[src.Resource] {
+				return nil, fmt.Errorf("alias %q: source %q is an alias", alias.Name, src.Resource)
+			}
+		}
+	}
+	return aliases, nil
+}
+
+// parseOverheadClasses parses --overhead-class flags of the form
+// name:namespaces=ns1,ns2 or name:selector=<label selector>. Both keys may be
+// combined with ";" to require both. Flags sharing a name add alternative
+// matchers to the same class.
+func parseOverheadClasses(flags []string) ([]OverheadClass, error) {
+	var classes []OverheadClass
+	index := make(map[string]int)
+	for _, f := range flags {
+		name, spec, ok := strings.Cut(f, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("overhead class %q: expected name:namespaces=... or name:selector=...", f)
+		}
+
+		var matcher OverheadMatcher
+		for _, part := range strings.Split(spec, ";") {
+			key, value, ok := strings.Cut(part, "=")
+			value = strings.TrimSpace(value)
+			switch strings.TrimSpace(key) {
+			case "namespaces":
+				if matcher.Namespaces != nil {
+					return nil, fmt.Errorf("overhead class %q: duplicate namespaces", f)
+				}
+				for _, ns := range strings.Split(value, ",") {
+					if ns = strings.TrimSpace(ns); ns != "" {
+						matcher.Namespaces = append(matcher.Namespaces, ns)
+					}
+				}
+				if len(matcher.Namespaces) == 0 {
+					return nil, fmt.Errorf("overhead class %q: empty namespaces", f)
+				}
+			case "selector":
+				if matcher.Selector != nil {
+					return nil, fmt.Errorf("overhead class %q: duplicate selector", f)
+				}
+				if value == "" {
+					return nil, fmt.Errorf("overhead class %q: empty selector", f)
+				}
+				selector, err := labels.Parse(value)
+				if err != nil {
+					return nil, fmt.Errorf("overhead class %q: invalid selector: %w", f, err)
+				}
+				matcher.Selector = selector
+			default:
+				if !ok {
+					return nil, fmt.Errorf("overhead class %q: expected key=value, got %q", f, part)
+				}
+				return nil, fmt.Errorf("overhead class %q: unknown key %q (want namespaces or selector)", f, key)
+			}
+		}
+
+		i, seen := index[name]
+		if !seen {
+			i = len(classes)
+			index[name] = i
+			classes = append(classes, OverheadClass{Name: name})
+		}
+		classes[i].Matchers = append(classes[i].Matchers, matcher)
+	}
+	return classes, nil
+}
+
+// overheadLabelKeys returns the pod label keys the overhead class selectors
+// match on, the only pod labels the informer cache needs to keep.
+func overheadLabelKeys(classes []OverheadClass) []string {
+	var keys []string
+	for _, class := range classes {
+		for _, m := range class.Matchers {
+			if m.Selector == nil {
+				continue
+			}
+			requirements, _ := m.Selector.Requirements()
+			for _, r := range requirements {
+				if !slices.Contains(keys, r.Key()) {
+					keys = append(keys, r.Key())
+				}
+			}
+		}
+	}
+	return keys
+}
+
+// parsePriorityThresholds parses the comma-separated --priority-thresholds
+// flag.
+func parsePriorityThresholds(csv string) ([]int32, error) {
+	var thresholds []int32
+	for _, p := range strings.Split(csv, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		v, err := strconv.ParseInt(p, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid priority %q: %w", p, err)
+		}
+		if slices.Contains(thresholds, int32(v)) {
+			return nil, fmt.Errorf("duplicate priority %d", v)
+		}
+		thresholds = append(thresholds, int32(v))
+	}
+	return thresholds, nil
+}
+
+// parseHistogramMode parses the --utilization-histogram flag.
+func parseHistogramMode(s string) (HistogramMode, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "none":
+		return HistogramNone, nil
+	case string(HistogramClassic):
+		return HistogramClassic, nil
+	case string(HistogramNative):
+		return HistogramNative, nil
+	default:
+		return HistogramNone, fmt.Errorf("unknown histogram mode %q (want none, classic or native)", s)
+	}
+}
+
+// parseConsolidationMode parses the --consolidation-mode flag.
+func parseConsolidationMode(s string) (ConsolidationMode, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", string(ConsolidationDominant):
+		return ConsolidationDominant, nil
+	case string(ConsolidationPerResource):
+		return ConsolidationPerResource, nil
+	default:
+		return "", fmt.Errorf("unknown consolidation mode %q (want dominant or per-resource)", s)
+	}
+}
+
+// parseNodeStateMode parses the --node-state-mode flag.
+func parseNodeStateMode(s string) (NodeStateMode, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", string(NodeStateInclude):
+		return NodeStateInclude, nil
+	case string(NodeStateExclude):
+		return NodeStateExclude, nil
+	case string(NodeStateSeparate):
+		return NodeStateSeparate, nil
+	default:
+		return "", fmt.Errorf("unknown node state mode %q (want include, exclude or separate)", s)
+	}
+}
+
 func parseLabelGroups(flags []string) [][]string {
 	var groups [][]string
 	for _, f := range flags {
@@ -5,6 +5,8 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -80,6 +82,31 @@ func TestParseResources(t *testing.T) {
 	}
 }
 
+// TestValidateResources tests that resource patterns are validated.
+func TestValidateResources(t *testing.T) {
+	tests := []struct {
+		name      string
+		resources []corev1.ResourceName
+		wantErr   bool
+	}{
+		{name: "exact names", resources: []corev1.ResourceName{"cpu", "memory", "nvidia.com/gpu"}, wantErr: false},
+		{name: "prefix pattern", resources: []corev1.ResourceName{"hugepages-*"}, wantErr: false},
+		{name: "domain pattern", resources: []corev1.ResourceName{"nvidia.com/*"}, wantErr: false},
+		{name: "auto", resources: []corev1.ResourceName{"auto"}, wantErr: false},
+		{name: "character class", resources: []corev1.ResourceName{"hugepages-[12]*"}, wantErr: false},
+		{name: "unclosed character class", resources: []corev1.ResourceName{"cpu", "hugepages-[12"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateResources(tt.resources)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateResources(%v) error = %v, wantErr %v", tt.resources, err, tt.wantErr)
+			}
+		})
+	}
+}
+
 // TestParseLabelGroups tests the parseLabelGroups function.
 func TestParseLabelGroups(t *testing.T) {
 	tests := []struct {
@@ -162,6 +189,206 @@ func TestParseLabelGroups(t *testing.T) {
 	}
 }
 
+// TestParseResourceAliases tests the parseResourceAliases function.
+func TestParseResourceAliases(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []string
+		expected []ResourceAlias
+		wantErr  bool
+	}{
+		{
+			name:     "nil input",
+			input:    nil,
+			expected: nil,
+		},
+		{
+			name:  "single alias with default weights",
+			input: []string{"gpu=nvidia.com/gpu+amd.com/gpu"},
+			expected: []ResourceAlias{
+				{Name: "gpu", Sources: []AliasSource{
+					{Resource: "nvidia.com/gpu", Weight: 1},
+					{Resource: "amd.com/gpu", Weight: 1},
+				}},
+			},
+		},
+		{
+			name:  "weighted MIG profile",
+			input: []string{"gpu = nvidia.com/gpu + nvidia.com/mig-1g.10gb:0.14"},
+			expected: []ResourceAlias{
+				{Name: "gpu", Sources: []AliasSource{
+					{Resource: "nvidia.com/gpu", Weight: 1},
+					{Resource: "nvidia.com/mig-1g.10gb", Weight: 0.14},
+				}},
+			},
+		},
+		{
+			name:  "multiple aliases",
+			input: []string{"gpu=nvidia.com/gpu", "hugepages=hugepages-2Mi+hugepages-1Gi"},
+			expected: []ResourceAlias{
+				{Name: "gpu", Sources: []AliasSource{{Resource: "nvidia.com/gpu", Weight: 1}}},
+				{Name: "hugepages", Sources: []AliasSource{
+					{Resource: "hugepages-2Mi", Weight: 1},
+					{Resource: "hugepages-1Gi", Weight: 1},
+				}},
+			},
+		},
+		{name: "missing equals", input: []string{"nvidia.com/gpu"}, wantErr: true},
+		{name: "empty name", input: []string{"=nvidia.com/gpu"}, wantErr: true},
+		{name: "empty source", input: []string{"gpu=nvidia.com/gpu+"}, wantErr: true},
+		{name: "invalid weight", input: []string{"gpu=nvidia.com/mig-1g.10gb:abc"}, wantErr: true},
+		{name: "negative weight", input: []string{"gpu=nvidia.com/mig-1g.10gb:-1"}, wantErr: true},
+		{name: "pattern name", input: []string{"gpu*=nvidia.com/gpu"}, wantErr: true},
+		{name: "duplicate name", input: []string{"gpu=nvidia.com/gpu", "gpu=amd.com/gpu"}, wantErr: true},
+		{name: "pattern source", input: []string{"gpu=nvidia.com/*"}, wantErr: true},
+		{name: "auto source", input: []string{"all=auto"}, wantErr: true},
+		{name: "alias source", input: []string{"gpu=nvidia.com/gpu", "accel=gpu+google.com/tpu"}, wantErr: true},
+		{name: "alias source defined later", input: []string{"accel=gpu+google.com/tpu", "gpu=nvidia.com/gpu"}, wantErr: true},
+		{name: "self source", input: []string{"gpu=gpu"}, wantErr: true},
+		{name: "name shadows tracked resource", input: []string{"cpu=cpu:1+nvidia.com/gpu:8"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseResourceAliases(tt.input, []corev1.ResourceName{"cpu", "memory", "nvidia.com/*"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseResourceAliases() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("parseResourceAliases() = %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestParseOverheadClasses tests the parseOverheadClasses function.
+func TestParseOverheadClasses(t *testing.T) {
+	platform := labels.SelectorFromSet(labels.Set{"platform": "true"})
+
+	tests := []struct {
+		name     string
+		input    []string
+		expected []OverheadClass
+		wantErr  bool
+	}{
+		{
+			name:     "nil input",
+			input:    nil,
+			expected: nil,
+		},
+		{
+			name:  "namespaces",
+			input: []string{"platform:namespaces=kube-system, monitoring"},
+			expected: []OverheadClass{
+				{Name: "platform", Matchers: []OverheadMatcher{{Namespaces: []string{"kube-system", "monitoring"}}}},
+			},
+		},
+		{
+			name:  "repeated name adds alternatives",
+			input: []string{"platform:namespaces=kube-system,monitoring", "platform:selector=platform=true"},
+			expected: []OverheadClass{
+				{Name: "platform", Matchers: []OverheadMatcher{
+					{Namespaces: []string{"kube-system", "monitoring"}},
+					{Selector: platform},
+				}},
+			},
+		},
+		{
+			name:  "namespaces and selector combined",
+			input: []string{"platform:namespaces=monitoring;selector=platform=true"},
+			expected: []OverheadClass{
+				{Name: "platform", Matchers: []OverheadMatcher{{Namespaces: []string{"monitoring"}, Selector: platform}}},
+			},
+		},
+		{
+			name:  "multiple classes keep flag order",
+			input: []string{"security:namespaces=falco", "platform:namespaces=kube-system"},
+			expected: []OverheadClass{
+				{Name: "security", Matchers: []OverheadMatcher{{Namespaces: []string{"falco"}}}},
+				{Name: "platform", Matchers: []OverheadMatcher{{Namespaces: []string{"kube-system"}}}},
+			},
+		},
+		{name: "missing colon", input: []string{"platform"}, wantErr: true},
+		{name: "empty name", input: []string{":namespaces=kube-system"}, wantErr: true},
+		{name: "empty spec", input: []string{"platform:"}, wantErr: true},
+		{name: "unknown key", input: []string{"platform:labels=platform=true"}, wantErr: true},
+		{name: "empty namespaces", input: []string{"platform:namespaces= , "}, wantErr: true},
+		{name: "empty selector", input: []string{"platform:selector="}, wantErr: true},
+		{name: "invalid selector", input: []string{"platform:selector=a==="}, wantErr: true},
+		{name: "duplicate key", input: []string{"platform:namespaces=a;namespaces=b"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseOverheadClasses(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseOverheadClasses() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("parseOverheadClasses() = %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestOverheadLabelKeys tests that overheadLabelKeys collects the label keys
+// of every overhead class selector once.
+func TestOverheadLabelKeys(t *testing.T) {
+	classes, err := parseOverheadClasses([]string{
+		"platform:namespaces=kube-system",
+		"platform:selector=platform=true,tier notin (batch)",
+		"security:namespaces=falco;selector=platform,!canary",
+	})
+	if err != nil {
+		t.Fatalf("parseOverheadClasses() error = %v", err)
+	}
+
+	got := overheadLabelKeys(classes)
+	slices.Sort(got)
+	if want := []string{"canary", "platform", "tier"}; !slices.Equal(got, want) {
+		t.Errorf("overheadLabelKeys() = %v, want %v", got, want)
+	}
+	if got := overheadLabelKeys(classes[:0]); got != nil {
+		t.Errorf("overheadLabelKeys(no classes) = %v, want nil", got)
+	}
+}
+
+// TestParsePriorityThresholds tests the parsePriorityThresholds function.
+func TestParsePriorityThresholds(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []int32
+		wantErr  bool
+	}{
+		{name: "empty", input: "", expected: nil},
+		{name: "single", input: "1000", expected: []int32{1000}},
+		{name: "keeps order and trims", input: " 1000000, 0 ,-10,", expected: []int32{1000000, 0, -10}},
+		{name: "system critical", input: "2000001000", expected: []int32{2000001000}},
+		{name: "not a number", input: "high", wantErr: true},
+		{name: "out of int32 range", input: "3000000000", wantErr: true},
+		{name: "duplicate", input: "100,100", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePriorityThresholds(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePriorityThresholds() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("parsePriorityThresholds() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
 // TestHealthEndpoint tests the /healthz liveness probe.
 func TestHealthEndpoint(t *testing.T) {
 	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
@@ -340,3 +567,83 @@ func TestNodeSelectorValidation(t *testing.T) {
 		})
 	}
 }
+
+// TestParseHistogramMode tests the parseHistogramMode function.
+func TestParseHistogramMode(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    HistogramMode
+		wantErr bool
+	}{
+		{input: "", want: HistogramNone},
+		{input: "none", want: HistogramNone},
+		{input: "classic", want: HistogramClassic},
+		{input: "Native", want: HistogramNative},
+		{input: "exponential", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseHistogramMode(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseHistogramMode(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseHistogramMode(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestParseConsolidationMode tests the parseConsolidationMode function.
+func TestParseConsolidationMode(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    ConsolidationMode
+		wantErr bool
+	}{
+		{input: "", want: ConsolidationDominant},
+		{input: "dominant", want: ConsolidationDominant},
+		{input: "Per-Resource", want: ConsolidationPerResource},
+		{input: "average", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseConsolidationMode(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseConsolidationMode(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseConsolidationMode(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestParseNodeStateMode tests the parseNodeStateMode function.
+func TestParseNodeStateMode(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    NodeStateMode
+		wantErr bool
+	}{
+		{input: "", want: NodeStateInclude},
+		{input: "include", want: NodeStateInclude},
+		{input: "Exclude", want: NodeStateExclude},
+		{input: "separate", want: NodeStateSeparate},
+		{input: "drop", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseNodeStateMode(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseNodeStateMode(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseNodeStateMode(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}